@@ -1,15 +1,115 @@
 package gocsv
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 var ErrHeaderEmpty = errors.New("empty header")
 
+var ErrRequiredField = errors.New("required field is empty")
+
+var (
+	ErrUnknownColumn = errors.New("columns without a matching field")
+	ErrMissingColumn = errors.New("fields without a matching column")
+	ErrFieldCount    = errors.New("wrong number of fields")
+)
+
+// The header of the column appended to the quarantined records
+const QuarantineErrorColumn = "error"
+
+// This type represents an error converting a CSV field into
+// a struct field. It can be retrieved with errors.As
+type ParseError struct {
+	Record int    // Record number, starting at 1 and excluding the header
+	Line   int    // Source line where the field starts
+	Column int    // Column index, starting at 0
+	Header string // Header name of the column
+	Field  string // Name of the struct field
+	Value  string // Raw value of the field
+	Err    error  // The conversion error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("decode: record %d, line %d, column %d (%s): cannot set field %s from %q: %v",
+		e.Record, e.Line, e.Column, e.Header, e.Field, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// This type describes the struct field bound to a column. The
+// field is empty when the column does not match any field
+type ColumnBinding struct {
+	Column int    // Column index, starting at 0
+	Header string // Header name of the column
+	Field  string // Name of the struct field
+}
+
+// This type collects the errors found by a lenient Decoder
+type DecodeErrors []error
+
+func (e DecodeErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("decode: %d errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e DecodeErrors) Unwrap() []error {
+	return e
+}
+
+// This type defines how the Decoder handles records that
+// can not be parsed or converted
+type ErrorPolicy int
+
+const (
+	// Stop decoding at the first error (default)
+	ErrorAbort ErrorPolicy = iota
+	// Skip the invalid records and collect their errors
+	ErrorSkipRecord
+	// Keep the invalid records leaving the zero value in the
+	// fields that could not be converted and collect their errors
+	ErrorZeroValue
+)
+
+// This type defines how the Decoder handles records with
+// less fields than the header
+type ShortRowPolicy int
+
+const (
+	// Fail with ErrFieldCount (default)
+	ShortRowError ShortRowPolicy = iota
+	// Treat the missing fields as empty values, which
+	// take the default value of the field if any
+	ShortRowPad
+)
+
+// This type defines how the Decoder handles records with
+// more fields than the header
+type LongRowPolicy int
+
+const (
+	// Fail with ErrFieldCount (default)
+	LongRowError LongRowPolicy = iota
+	// Ignore the fields after the header
+	LongRowTruncate
+	// Capture the fields after the header in the extra field,
+	// keyed by their column index
+	LongRowExtra
+)
+
 // This interface represents a CSV reader (by default csv.Reader)
 // It allows the user to customize the reader used
 type CSVReader interface {
@@ -17,6 +117,12 @@ type CSVReader interface {
 	ReadAll() ([][]string, error)
 }
 
+// This interface is implemented by CSV readers that report the
+// position of the fields of the last record read (by default csv.Reader)
+type fieldPositioner interface {
+	FieldPos(field int) (line, column int)
+}
+
 // This interface defines a contract to define custom
 // types that could be unmarshaled
 type Unmarshaler interface {
@@ -25,23 +131,200 @@ type Unmarshaler interface {
 
 // The Decoder type used to decode a *.csv file
 type Decoder struct {
-	reader         CSVReader
-	header         []string
-	containsHeader bool
-	currentLine    int
-	err            error
+	reader                 CSVReader
+	header                 []string
+	containsHeader         bool
+	currentLine            int
+	currentRecord          int
+	err                    error
+	errorPolicy            ErrorPolicy
+	maxErrors              int
+	timeLayout             string
+	naming                 NamingStrategy
+	normalizer             HeaderNormalizer
+	disallowUnknownColumns bool
+	requireAllColumns      bool
+	shortRowPolicy         ShortRowPolicy
+	longRowPolicy          LongRowPolicy
+	quarantine             CSVWriter
+	quarantineStarted      bool
+	typeInfo               *typeInfo
+	columns                []int
+	defaults               []int
+	convOpts               []convOptions
+	cloneStrings           bool
+	concurrency            int
 }
 
 // This function creates a CSV Decoder and returns it
 func NewDecoder(r io.Reader) *Decoder {
+	// The number of fields is checked by the Decoder
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
 	return &Decoder{
-		reader:      csv.NewReader(r),
+		reader:      reader,
 		header:      nil,
 		currentLine: 0,
 		err:         nil,
 	}
 }
 
+// This type defines a function that configures a Decoder
+type DecoderOption func(d *Decoder)
+
+// This function returns an iterator over the records of a CSV document.
+// Every option is applied to the Decoder before decoding. T should be a
+// struct or a pointer to a struct. The iteration stops after the first error,
+// the errors collected by a lenient Decoder are yielded at the end
+func All[T any](r io.Reader, opts ...DecoderOption) iter.Seq2[T, error] {
+	return AllContext[T](context.Background(), r, opts...)
+}
+
+// This function returns an iterator over the records of a CSV document,
+// like All, which stops with the error of the context once it is done
+func AllContext[T any](ctx context.Context, r io.Reader, opts ...DecoderOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		d := NewDecoder(r)
+		for _, opt := range opts {
+			opt(d)
+		}
+
+		if d.concurrency > 1 {
+			allParallel(ctx, d, yield)
+			return
+		}
+
+		typ := reflect.TypeFor[T]()
+		for {
+			var record T
+			var err error
+			if typ.Kind() == reflect.Pointer {
+				record = reflect.New(typ.Elem()).Interface().(T)
+				err = d.DecodeRecordContext(ctx, record)
+			} else {
+				err = d.DecodeRecordContext(ctx, &record)
+			}
+
+			if errors.Is(err, io.EOF) {
+				// Report the errors collected by a lenient Decoder
+				if d.Error() != nil {
+					var zero T
+					yield(zero, d.Error())
+				}
+				return
+			}
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// This function sets how the Decoder reacts to invalid records
+func (d *Decoder) SetErrorPolicy(policy ErrorPolicy) {
+	d.errorPolicy = policy
+}
+
+// This function sets the number of errors after which a lenient
+// Decoder stops decoding. Zero means there is no limit
+func (d *Decoder) SetMaxErrors(n int) {
+	d.maxErrors = n
+}
+
+// This function sets a CSV writer where a lenient Decoder writes
+// every rejected record, followed by a column with its errors
+func (d *Decoder) SetQuarantine(create func() CSVWriter) {
+	d.quarantine = create()
+}
+
+// This function makes the default CSV reader reuse the slice of the
+// previous record, so decoding a record with DecodeRecord does not
+// allocate besides the reader. A custom reader should be configured
+// by the user
+func (d *Decoder) ReuseRecord(v bool) {
+	if reader, ok := d.reader.(*csv.Reader); ok {
+		reader.ReuseRecord = v
+	}
+}
+
+// This function makes the Decoder copy the values of string fields.
+// Otherwise they may share the memory of the whole record read
+func (d *Decoder) CloneStrings(v bool) {
+	d.cloneStrings = v
+}
+
+// This function sets the default layout of the time.Time fields
+// that do not define one in their tag
+func (d *Decoder) SetTimeLayout(layout string) {
+	d.timeLayout = layout
+}
+
+// This function sets the naming strategy used to derive the
+// header name of the fields without a csv tag
+func (d *Decoder) SetNamingStrategy(naming NamingStrategy) {
+	d.naming = naming
+}
+
+// This function makes the Decoder fail when the header contains
+// columns that do not match any struct field. It has no effect on
+// documents without a header, whose columns are bound by position
+func (d *Decoder) DisallowUnknownColumns(v bool) {
+	d.disallowUnknownColumns = v
+}
+
+// This function makes the Decoder fail when a struct field
+// does not match any column of the header
+func (d *Decoder) RequireAllColumns(v bool) {
+	d.requireAllColumns = v
+}
+
+// This function sets how the Decoder handles records with
+// less fields than the header
+func (d *Decoder) SetShortRowPolicy(policy ShortRowPolicy) {
+	d.shortRowPolicy = policy
+}
+
+// This function sets how the Decoder handles records with
+// more fields than the header
+func (d *Decoder) SetLongRowPolicy(policy LongRowPolicy) {
+	d.longRowPolicy = policy
+}
+
+// This function sets the normalizer applied to the header names of
+// the document and the fields when they do not match otherwise
+func (d *Decoder) SetHeaderNormalizer(normalizer HeaderNormalizer) {
+	d.normalizer = normalizer
+}
+
+// This function returns how the columns of the header were bound to
+// the struct fields. It is available once the decoding has started
+func (d *Decoder) ColumnBindings() []ColumnBinding {
+	if d.typeInfo == nil {
+		return nil
+	}
+
+	bindings := make([]ColumnBinding, 0, len(d.columns))
+	for j, i := range d.columns {
+		binding := ColumnBinding{Column: j}
+		if j < len(d.header) {
+			binding.Header = d.header[j]
+		}
+		if i >= 0 {
+			binding.Field = d.typeInfo.fields[i].fName
+		} else if d.typeInfo.extra != nil {
+			binding.Field = d.typeInfo.extra.fName
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings
+}
+
 // This function toggles the header parsing for a CSV document
 func (d *Decoder) ContainsHeader(v bool) {
 	d.containsHeader = v
@@ -60,6 +343,13 @@ func (d *Decoder) Error() error {
 // This function decodes a CSV into the passed structure which
 // should be a pointer to a slice of records
 func (d *Decoder) Decode(out any) error {
+	return d.DecodeContext(context.Background(), out)
+}
+
+// This function decodes a CSV into the passed structure like Decode.
+// The context is checked before every record, once it is done the
+// decoding stops with its error
+func (d *Decoder) DecodeContext(ctx context.Context, out any) error {
 	outVal, outType := getOutValueAndType(out)
 	if err := ensureOutType(outType); err != nil {
 		return err
@@ -70,60 +360,673 @@ func (d *Decoder) Decode(out any) error {
 		return err
 	}
 
-	typeInfo, err := getTypeInfo(outInnerType)
+	if err := d.prepare(outInnerType); err != nil {
+		return err
+	}
+
+	if err := ensureOutSettable(outVal); err != nil {
+		return err
+	}
+	outVal.Set(reflect.MakeSlice(outType, 0, 0))
+
+	firstRecord := d.currentRecord
+	if err := d.decodeEach(ctx, wasInnerPointer, func(record reflect.Value) bool {
+		outVal.Set(reflect.Append(outVal, record))
+		return true
+	}); err != nil {
+		return err
+	}
+
+	if d.currentRecord == firstRecord {
+		return errors.New("decode: empty CSV file")
+	}
+
+	return d.err
+}
+
+// This function decodes the next CSV record into the passed structure
+// which should be a pointer to a struct. It returns io.EOF when there
+// are no more records to decode. The errors collected by a lenient
+// Decoder are available through Error
+func (d *Decoder) DecodeRecord(out any) error {
+	return d.DecodeRecordContext(context.Background(), out)
+}
+
+// This function decodes the next CSV record like DecodeRecord. When
+// the context is done it returns its error instead
+func (d *Decoder) DecodeRecordContext(ctx context.Context, out any) error {
+	if err := d.checkContext(ctx, d.currentRecord); err != nil {
+		return err
+	}
+
+	outVal := reflect.ValueOf(out)
+	if outVal.Kind() != reflect.Pointer || outVal.IsNil() {
+		return fmt.Errorf("decode: expected a non nil pointer to a struct (%T)", out)
+	}
+
+	outVal = outVal.Elem()
+	if err := ensureOutInnerType(outVal.Type()); err != nil {
+		return err
+	}
+
+	if err := d.prepare(outVal.Type()); err != nil {
+		return err
+	}
+
+	return d.decodeNext(outVal)
+}
+
+// This function decodes the CSV records and sends them to the passed
+// channel as they are decoded. The channel should be a chan T or a chan *T
+// where T is a struct, and it is closed once the decoding stops. When the
+// context is done the decoding stops with its error. The errors collected
+// by a lenient Decoder are returned at the end
+func (d *Decoder) DecodeToChan(ctx context.Context, ch any) error {
+	chVal := reflect.ValueOf(ch)
+	if chVal.Kind() != reflect.Chan || chVal.IsNil() || chVal.Type().ChanDir()&reflect.SendDir == 0 {
+		return fmt.Errorf("decode: expected a non nil channel to send the records (%T)", ch)
+	}
+	defer chVal.Close()
+
+	wasInnerPointer, outInnerType := getOutInnerType(chVal.Type())
+	if err := ensureOutInnerType(outInnerType); err != nil {
+		return err
+	}
+
+	if err := d.prepare(outInnerType); err != nil {
+		return err
+	}
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: chVal},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	}
+	done := false
+	if err := d.decodeEach(ctx, wasInnerPointer, func(record reflect.Value) bool {
+		cases[0].Send = record
+		chosen, _, _ := reflect.Select(cases)
+		done = chosen == 1
+		return !done
+	}); err != nil {
+		return err
+	}
+
+	if done {
+		return d.checkContext(ctx, d.currentRecord)
+	}
+	return d.err
+}
+
+// This function returns the number of lines read by the Decoder,
+// including the header. A CSV reader that does not report the position
+// of the fields counts every record as a single line
+func (d *Decoder) CurrentLine() int {
+	return d.currentLine
+}
+
+// This function computes the type information, the header and the
+// column binding for the given type. It is done once per Decoder,
+// subsequent calls only check that the type has not changed. Nothing
+// is stored until every check succeeds, so a failed call fails again
+// when it is retried. The header of the document is read only once
+func (d *Decoder) prepare(typ reflect.Type) error {
+	if d.typeInfo != nil {
+		if d.typeInfo.parentType != typ {
+			return fmt.Errorf("decode: type %s differs from previously decoded type %s", typ.String(), d.typeInfo.parentType.String())
+		}
+		return nil
+	}
+
+	typeInfo, err := getTypeInfo(typ)
 	if err != nil {
 		return err
 	}
 
-	if len(typeInfo.fields) == 0 {
+	if len(typeInfo.fields) == 0 && typeInfo.extra == nil {
 		return errors.New("decode: expected fields to decode")
 	}
 
-	// Decode the header from the struct tags
+	if d.longRowPolicy == LongRowExtra && typeInfo.extra == nil {
+		return fmt.Errorf("decode: type %s has no extra field to capture long rows", typ.String())
+	}
+
+	// The default values are checked once, they are not data errors
+	convOpts := typeInfo.convOptions(convOptions{layout: d.timeLayout, cloneStrings: d.cloneStrings})
+	for i, field := range typeInfo.fields {
+		if !field.hasDefault {
+			continue
+		}
+		value := reflect.New(typ.FieldByIndex(field.index).Type).Elem()
+		if err := field.decode(value, field.defaultValue, &convOpts[i]); err != nil {
+			return fmt.Errorf("decode: default value %q of field %s: %w", field.defaultValue, field.fName, err)
+		}
+	}
+
+	header := d.header
+	var columns []int
 	if !d.containsHeader {
-		d.header = make([]string, 0, len(typeInfo.fields))
-		for _, v := range typeInfo.fields {
-			d.header = append(d.header, v.fTag)
+		// Without a header the columns are bound by position and their
+		// names are taken from the struct tags, or the column index
+		columns = typeInfo.columnLayout()
+		header = typeInfo.layoutHeader(columns, d.naming)
+		for j, i := range columns {
+			if i < 0 {
+				header[j] = strconv.Itoa(j)
+			}
 		}
 	} else {
 		// Decode header from the input
-		if err := d.decodeHeader(); err != nil {
+		if header == nil {
+			if err := d.decodeHeader(); err != nil {
+				return err
+			}
+			header = d.header
+		}
+		if columns, err = d.bindColumns(typeInfo, header); err != nil {
 			return err
 		}
 	}
 
-	lines, err := d.reader.ReadAll()
+	defaults, err := d.checkBinding(typeInfo, header, columns)
 	if err != nil {
 		return err
 	}
 
-	if len(lines) == 0 {
-		return errors.New("decode: empty CSV file")
+	d.typeInfo = typeInfo
+	d.convOpts = convOpts
+	d.header = header
+	d.columns = columns
+	d.defaults = defaults
+	return nil
+}
+
+// This function decodes every remaining record into a new value and
+// passes it to emit, until the end of the file or emit returns false.
+// The records are converted in parallel when the Decoder has
+// a concurrency level
+func (d *Decoder) decodeEach(ctx context.Context, wasInnerPointer bool, emit func(record reflect.Value) bool) error {
+	if d.concurrency > 1 {
+		return d.decodeParallel(ctx, wasInnerPointer, emit)
 	}
 
-	if len(lines[0]) != len(d.header) {
-		return fmt.Errorf("decode: header len (%d) is not equal to content len (%d)", len(lines[0]), len(d.header))
+	for {
+		if err := d.checkContext(ctx, d.currentRecord); err != nil {
+			return err
+		}
+
+		record := getNewOutInnerValue(wasInnerPointer, d.typeInfo.parentType)
+		out := record
+		if wasInnerPointer {
+			out = record.Elem()
+		}
+
+		err := d.decodeNext(out)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !emit(record) {
+			return nil
+		}
+	}
+}
+
+// This function returns the error of the context once it is done,
+// along with the number of the last record read
+func (d *Decoder) checkContext(ctx context.Context, record int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("decode: record %d: %w", record, err)
+	}
+	return nil
+}
+
+// This function decodes the next valid record into the given struct
+// value. Depending on the error policy, invalid records are skipped
+// and their errors collected until the error limit is reached
+func (d *Decoder) decodeNext(out reflect.Value) error {
+	for {
+		line, err := d.readLine()
+		if err == nil {
+			out.SetZero()
+			var fieldErrs []error
+			fieldErrs, err = d.decodeLine(line, out, d.currentPos(line))
+			if err == nil {
+				// The record is kept with the fields that failed set to zero
+				if len(fieldErrs) > 0 {
+					return d.rejectLine(line, fieldErrs...)
+				}
+				return nil
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		if err := d.rejectLine(line, err); err != nil {
+			return err
+		}
+	}
+}
+
+// This function handles a record that could not be decoded. When the
+// Decoder is lenient the record is written to the quarantine and its
+// errors are collected. It returns an error when the decoding should stop
+func (d *Decoder) rejectLine(line []string, errs ...error) error {
+	for _, err := range errs {
+		if d.errorPolicy == ErrorAbort || !isRecoverable(err) {
+			return err
+		}
+	}
+
+	if err := d.quarantineLine(line, errs); err != nil {
+		return err
+	}
+
+	for _, err := range errs {
+		if err := d.collectError(err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// This function writes a rejected record followed by its error
+// messages to the quarantine writer, if any. When the document
+// contains a header it is written before the first rejected record
+func (d *Decoder) quarantineLine(line []string, errs []error) error {
+	if d.quarantine == nil {
+		return nil
+	}
+
+	if d.containsHeader && !d.quarantineStarted {
+		header := append(append(make([]string, 0, len(d.header)+1), d.header...), QuarantineErrorColumn)
+		if err := d.quarantine.Write(header); err != nil {
+			return err
+		}
+	}
+	d.quarantineStarted = true
+
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	record := append(append(make([]string, 0, len(line)+1), line...), strings.Join(msgs, "; "))
+	if err := d.quarantine.Write(record); err != nil {
+		return err
+	}
+
+	if flusher, ok := d.quarantine.(csvFlusher); ok {
+		flusher.Flush()
+		return flusher.Error()
 	}
+	return nil
+}
 
-	if err := ensureOutCapacity(outVal, len(lines)); err != nil {
+// This function stores a recoverable error when the Decoder is
+// lenient. It returns an error when the decoding should stop
+func (d *Decoder) collectError(err error) error {
+	if d.errorPolicy == ErrorAbort || !isRecoverable(err) {
 		return err
 	}
 
-	for i, line := range lines {
-		outInnerValue := getNewOutInnerValue(wasInnerPointer, outInnerType)
-		for j, value := range line {
-			oi := outInnerValue
-			if wasInnerPointer {
-				oi = outInnerValue.Elem()
+	errs, _ := d.err.(DecodeErrors)
+	errs = append(errs, err)
+	d.err = errs
+
+	if d.maxErrors > 0 && len(errs) >= d.maxErrors {
+		return errs
+	}
+	return nil
+}
+
+// This function returns true if the decoding may continue after
+// the given error, which happens on conversion and syntax errors
+func isRecoverable(err error) bool {
+	var decodeErrs DecodeErrors
+	if errors.As(err, &decodeErrs) {
+		return false
+	}
+
+	var parseErr *ParseError
+	var csvErr *csv.ParseError
+	return errors.As(err, &parseErr) || errors.As(err, &csvErr) || errors.Is(err, ErrFieldCount)
+}
+
+// This function reads the next record from the CSV reader
+// and updates the line and record counters. Malformed records
+// are counted too, since the decoding may continue after them
+func (d *Decoder) readLine() ([]string, error) {
+	line, err := d.reader.Read()
+	if err != nil {
+		if isRecoverable(err) {
+			var csvErr *csv.ParseError
+			if errors.As(err, &csvErr) {
+				d.currentLine = csvErr.Line
+			} else {
+				d.currentLine++
 			}
-			if err := setValue(oi.FieldByIndex(typeInfo.fields[j].index), value); err != nil {
-				return err
+			d.currentRecord++
+		}
+		// The reader may return the record along with the error
+		return line, err
+	}
+
+	d.countLines(line)
+	d.currentRecord++
+	return line, nil
+}
+
+// This function advances the line counter to the end of the last record
+// read. Quoted fields may span several lines, so the lines are taken from
+// the CSV reader when it is able to report the position of the fields
+func (d *Decoder) countLines(line []string) {
+	positioner, ok := d.reader.(fieldPositioner)
+	if !ok || len(line) == 0 {
+		d.currentLine++
+		return
+	}
+
+	last := len(line) - 1
+	d.currentLine, _ = positioner.FieldPos(last)
+	d.currentLine += strings.Count(line[last], "\n")
+}
+
+// This type holds the position of a record in the document
+type recordPos struct {
+	record int
+	// Line where the record starts
+	line int
+	// Number of fields read, before applying the row policies
+	fields int
+	// The record is the last one read, so the reader is
+	// able to report the position of its fields
+	current bool
+	// Lines where the fields start, kept for the records
+	// decoded after the next one has been read
+	fieldLines []int
+}
+
+// This function returns the position of the last record read. The
+// line is taken from the reader when it is able to report it
+func (d *Decoder) currentPos(line []string) recordPos {
+	pos := recordPos{record: d.currentRecord, line: d.currentLine, fields: len(line), current: true}
+	if positioner, ok := d.reader.(fieldPositioner); ok && len(line) > 0 {
+		pos.line, _ = positioner.FieldPos(0)
+	}
+	return pos
+}
+
+// This function sets every bound column of a CSV line into the fields
+// of the given struct value. It returns the errors of the fields left
+// with their zero value, which do not discard the record. It does not
+// modify the Decoder, so lines may be decoded concurrently
+func (d *Decoder) decodeLine(line []string, out reflect.Value, pos recordPos) ([]error, error) {
+	line, err := d.fitLine(line, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra reflect.Value
+	var fieldErrs []error
+	for j, value := range line {
+		if j >= len(d.columns) || d.columns[j] < 0 {
+			// Unbound columns are captured by the extra field
+			if d.typeInfo.extra != nil {
+				if !extra.IsValid() {
+					extra = out.FieldByIndex(d.typeInfo.extra.index)
+					extra.Set(reflect.MakeMap(extraMapType))
+				}
+				extra.SetMapIndex(reflect.ValueOf(d.columnName(j)), reflect.ValueOf(value))
+			}
+			continue
+		}
+		i := d.columns[j]
+		field := &d.typeInfo.fields[i]
+		fieldVal := out.FieldByIndex(field.index)
+		if err := d.decodeField(fieldVal, i, value); err != nil {
+			parseErr := d.newParseError(pos, j, field, value, err)
+			if d.errorPolicy != ErrorZeroValue {
+				return nil, parseErr
 			}
+			// Keep the zero value and continue with the next field
+			fieldVal.SetZero()
+			fieldErrs = append(fieldErrs, parseErr)
 		}
-		outVal.Index(i).Set(outInnerValue)
 	}
 
-	return nil
+	// Fields without a column take their default value
+	for _, i := range d.defaults {
+		field := &d.typeInfo.fields[i]
+		if err := field.decode(out.FieldByIndex(field.index), field.defaultValue, &d.convOpts[i]); err != nil {
+			return nil, fmt.Errorf("decode: default value of field %s: %w", field.fName, err)
+		}
+	}
+
+	return fieldErrs, nil
+}
+
+// This function checks the length of a line against the header and
+// applies the row policies. Short lines may be padded with empty values
+// and long lines truncated, or kept to be captured by the extra field
+func (d *Decoder) fitLine(line []string, pos recordPos) ([]string, error) {
+	switch {
+	case len(line) < len(d.header):
+		if d.shortRowPolicy != ShortRowPad {
+			return nil, d.newFieldCountError(pos, len(line))
+		}
+		return append(line[:len(line):len(line)], make([]string, len(d.header)-len(line))...), nil
+	case len(line) > len(d.header):
+		// Fields bound by index allow to extract some columns of longer lines
+		sparse := !d.containsHeader && d.typeInfo.hasColumnIndex()
+		switch {
+		case d.longRowPolicy == LongRowExtra:
+			return line, nil
+		case d.longRowPolicy == LongRowTruncate || sparse:
+			return line[:len(d.header)], nil
+		default:
+			return nil, d.newFieldCountError(pos, len(line))
+		}
+	}
+
+	return line, nil
+}
+
+// This function creates the error of a line with a wrong number of fields
+func (d *Decoder) newFieldCountError(pos recordPos, length int) error {
+	return fmt.Errorf("decode: record %d, line %d: %w: %d fields, expected %d",
+		pos.record, pos.line, ErrFieldCount, length, len(d.header))
+}
+
+// This function returns the name of a column. Columns after the
+// header are named after their index
+func (d *Decoder) columnName(column int) string {
+	if column < len(d.header) {
+		return d.header[column]
+	}
+	return strconv.Itoa(column)
+}
+
+// This function sets the value of a column into a field, applying
+// the default and required options of its tag
+func (d *Decoder) decodeField(out reflect.Value, i int, value string) error {
+	field := &d.typeInfo.fields[i]
+	if value == "" {
+		if field.required {
+			return ErrRequiredField
+		}
+		if field.hasDefault {
+			value = field.defaultValue
+		}
+	}
+
+	return field.decode(out, value, &d.convOpts[i])
+}
+
+// This function creates a ParseError for the given column of a record.
+// The source line of a field of the last record read is taken from the
+// CSV reader when it is able to report it, or from the lines kept
+// along with the position of the record. The fields added by the short
+// row policy take the line where the record starts
+func (d *Decoder) newParseError(pos recordPos, column int, field *fieldInfo, value string, err error) *ParseError {
+	parseErr := &ParseError{
+		Record: pos.record,
+		Line:   pos.line,
+		Column: column,
+		Field:  field.fName,
+		Value:  value,
+		Err:    err,
+	}
+
+	if column < len(d.header) {
+		parseErr.Header = d.header[column]
+	}
+
+	positioner, ok := d.reader.(fieldPositioner)
+	switch {
+	case column < len(pos.fieldLines):
+		parseErr.Line = pos.fieldLines[column]
+	case ok && pos.current && column < pos.fields:
+		parseErr.Line, _ = positioner.FieldPos(column)
+	}
+
+	return parseErr
+}
+
+// This function builds the column to field index from the header.
+// Every position of the returned slice holds the index of the field
+// bound to that column, or -1 if the column does not match any field
+func (d *Decoder) bindColumns(typeInfo *typeInfo, header []string) ([]int, error) {
+	columns := make([]int, len(header))
+	for j := range columns {
+		columns[j] = -1
+	}
+
+	// Fields with an explicit index are bound regardless of the header
+	bound := make([]bool, len(typeInfo.fields))
+	for i, field := range typeInfo.fields {
+		if !field.hasIndex {
+			continue
+		}
+		if field.columnIndex >= len(columns) {
+			return nil, fmt.Errorf("decode: column index %d of field %s is outside the header of %d columns",
+				field.columnIndex, field.fName, len(columns))
+		}
+		columns[field.columnIndex] = i
+		bound[i] = true
+	}
+
+	// Every field is matched by its header name and its aliases
+	names := make([][]string, 0, len(typeInfo.fields))
+	for i, name := range typeInfo.headerNames(d.naming) {
+		names = append(names, append([]string{name}, typeInfo.fields[i].aliases...))
+	}
+
+	for j, name := range header {
+		if columns[j] >= 0 {
+			continue
+		}
+		columns[j] = d.matchField(typeInfo, names, bound, name)
+		if columns[j] >= 0 {
+			bound[columns[j]] = true
+			continue
+		}
+
+		// Two names of a field with aliases can not appear in the same header
+		all := make([]bool, len(typeInfo.fields))
+		if i := d.matchField(typeInfo, names, all, name); i >= 0 && len(typeInfo.fields[i].aliases) > 0 {
+			first := slices.Index(columns, i)
+			return nil, fmt.Errorf("decode: columns %d (%s) and %d (%s) are both bound to field %s",
+				first, header[first], j, name, typeInfo.fields[i].fName)
+		}
+	}
+
+	return columns, nil
+}
+
+// This function checks the column binding. Unknown columns and
+// missing fields are reported when the Decoder is strict and required
+// fields must always be bound. It returns the fields with a default
+// value and no column, which are set on every record
+func (d *Decoder) checkBinding(typeInfo *typeInfo, header []string, columns []int) ([]int, error) {
+	var unknown []string
+	bound := make([]bool, len(typeInfo.fields))
+	for j, i := range columns {
+		// Without a header the columns are laid out from the fields,
+		// so the gaps left by the column indexes are not unknown
+		if i >= 0 {
+			bound[i] = true
+		} else if d.containsHeader {
+			unknown = append(unknown, header[j])
+		}
+	}
+
+	// The unknown columns are captured by the extra field, if any
+	if d.disallowUnknownColumns && len(unknown) > 0 && typeInfo.extra == nil {
+		return nil, fmt.Errorf("decode: %w: %s", ErrUnknownColumn, strings.Join(unknown, ", "))
+	}
+
+	var missing []string
+	var defaults []int
+	for i, field := range typeInfo.fields {
+		if bound[i] {
+			continue
+		}
+		if field.required {
+			return nil, fmt.Errorf("decode: required field %s (%s) has no column", field.fName, field.headerName(d.naming))
+		}
+		if field.hasDefault {
+			defaults = append(defaults, i)
+		}
+		missing = append(missing, field.headerName(d.naming))
+	}
+
+	if d.requireAllColumns && len(missing) > 0 {
+		return nil, fmt.Errorf("decode: %w: %s", ErrMissingColumn, strings.Join(missing, ", "))
+	}
+
+	return defaults, nil
+}
+
+// This function returns the index of the first unbound field that
+// matches the header name, or -1 if none matches. Exact matches of the
+// field header names and aliases are preferred over case insensitive
+// ones, and these over the ones of the header normalizer. Fields without
+// a tag also match their field name
+func (d *Decoder) matchField(typeInfo *typeInfo, names [][]string, bound []bool, name string) int {
+	matchers := []func(i int) bool{
+		func(i int) bool { return slices.Contains(names[i], name) },
+		func(i int) bool {
+			return slices.ContainsFunc(names[i], func(n string) bool { return strings.EqualFold(n, name) })
+		},
+		func(i int) bool {
+			return typeInfo.fields[i].fTag == "" && strings.EqualFold(typeInfo.fields[i].fName, name)
+		},
+	}
+
+	if d.normalizer != nil {
+		normalized := d.normalizer(name)
+		matchers = append(matchers,
+			func(i int) bool {
+				return slices.ContainsFunc(names[i], func(n string) bool { return d.normalizer(n) == normalized })
+			},
+			func(i int) bool {
+				return typeInfo.fields[i].fTag == "" && d.normalizer(typeInfo.fields[i].fName) == normalized
+			},
+		)
+	}
+
+	for _, match := range matchers {
+		for i := range typeInfo.fields {
+			if !bound[i] && match(i) {
+				return i
+			}
+		}
+	}
+
+	return -1
 }
 
 // This function creates a new inner type value
@@ -178,16 +1081,11 @@ func ensureOutInnerType(outInnerType reflect.Type) error {
 	}
 }
 
-// This function ensures that the out value has enough capacity to
-// fit every CSV record
-func ensureOutCapacity(out reflect.Value, lenght int) error {
-	switch out.Kind() {
-	case reflect.Slice:
-		if !out.CanAddr() && out.Len() < lenght {
-			return fmt.Errorf("decode: out value is not addressable and it has not enough lenght (%d)", out.Len())
-		} else {
-			out.Set(reflect.MakeSlice(out.Type(), lenght, lenght))
-		}
+// This function ensures that the out value can be
+// replaced with the decoded records
+func ensureOutSettable(out reflect.Value) error {
+	if !out.CanSet() {
+		return fmt.Errorf("decode: out value is not addressable (%s)", out.Type().String())
 	}
 
 	return nil
@@ -195,18 +1093,15 @@ func ensureOutCapacity(out reflect.Value, lenght int) error {
 
 // This function decodes a header of a CSV document
 func (d *Decoder) decodeHeader() error {
-	if d.header == nil {
-		d.header = make([]string, 0)
-	}
-
 	line, err := d.reader.Read()
 	if err != nil {
 		return err
 	}
 
-	d.header = line
-	if len(d.header) == 0 {
+	d.countLines(line)
+	if len(line) == 0 {
 		return ErrHeaderEmpty
 	}
+	d.header = slices.Clone(line)
 	return nil
 }
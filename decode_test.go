@@ -1,7 +1,15 @@
 package gocsv_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/netip"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -130,3 +138,997 @@ func TestDecodeWithCustomType(t *testing.T) {
 		assert.EqualValues(t, expected[i], *v)
 	}
 }
+
+func TestDecodeWithReorderedHeader(t *testing.T) {
+	input := "Age,Unknown,Name\n25,x,John\n50,y,Michael\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []A
+	assert.Nil(t, decoder.Decode(&records))
+
+	expected := []A{
+		{"John", 25},
+		{"Michael", 50},
+	}
+
+	assert.Equal(t, expected, records)
+}
+
+func TestDecodeRecord(t *testing.T) {
+	input := "name,age\nJohn,25\nMichael,50\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+
+	expected := []A{
+		{"John", 25},
+		{"Michael", 50},
+	}
+
+	var record A
+	for i, v := range expected {
+		assert.Nil(t, decoder.DecodeRecord(&record))
+		assert.Equal(t, v, record)
+		assert.Equal(t, i+2, decoder.CurrentLine())
+	}
+
+	assert.ErrorIs(t, decoder.DecodeRecord(&record), io.EOF)
+}
+
+func TestAll(t *testing.T) {
+	input := "age,name\n25,John\n50,Michael\n"
+
+	expected := []*A{
+		{"John", 25},
+		{"Michael", 50},
+	}
+
+	var records []*A
+	for record, err := range gocsv.All[*A](strings.NewReader(input), func(d *gocsv.Decoder) {
+		d.ContainsHeader(true)
+	}) {
+		assert.Nil(t, err)
+		records = append(records, record)
+	}
+
+	assert.Equal(t, expected, records)
+}
+
+func TestAllWithError(t *testing.T) {
+	input := "John,25\nMichael,fifty\nJane,23\n"
+
+	var count int
+	var lastErr error
+	for _, err := range gocsv.All[A](strings.NewReader(input)) {
+		count++
+		lastErr = err
+	}
+
+	assert.Equal(t, 2, count)
+	assert.NotNil(t, lastErr)
+}
+
+func TestDecodeParseError(t *testing.T) {
+	input := "name,age\nJohn,25\n\"Michael\nDoe\",fifty\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []A
+	err := decoder.Decode(&records)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 2, parseErr.Record)
+	assert.Equal(t, 4, parseErr.Line)
+	assert.Equal(t, 1, parseErr.Column)
+	assert.Equal(t, "age", parseErr.Header)
+	assert.Equal(t, "Age", parseErr.Field)
+	assert.Equal(t, "fifty", parseErr.Value)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+func TestDecodeSkipInvalidRecords(t *testing.T) {
+	input := "John,25\nMichael,fifty\nJane,23\nDoe,x\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	var records []A
+	err := decoder.Decode(&records)
+
+	var decodeErrs gocsv.DecodeErrors
+	assert.ErrorAs(t, err, &decodeErrs)
+	assert.Len(t, decodeErrs, 2)
+	assert.Equal(t, decodeErrs, decoder.Error())
+	assert.Equal(t, []A{{"John", 25}, {"Jane", 23}}, records)
+}
+
+func TestDecodeZeroValueOnInvalidFields(t *testing.T) {
+	input := "John,25\nMichael,fifty\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.SetErrorPolicy(gocsv.ErrorZeroValue)
+	var records []A
+	err := decoder.Decode(&records)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 2, parseErr.Record)
+	assert.Equal(t, []A{{"John", 25}, {"Michael", 0}}, records)
+}
+
+func TestDecodeMaxErrors(t *testing.T) {
+	input := "John,x\nMichael,y\nJane,23\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	decoder.SetMaxErrors(2)
+	var records []A
+	err := decoder.Decode(&records)
+
+	var decodeErrs gocsv.DecodeErrors
+	assert.ErrorAs(t, err, &decodeErrs)
+	assert.Len(t, decodeErrs, 2)
+	assert.Empty(t, records)
+}
+
+func TestDecodeSkipMalformedRecord(t *testing.T) {
+	input := "name,age\nJo\"hn,25\nJane,x\n"
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.ContainsHeader(true)
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	var records []A
+	err := decoder.Decode(&records)
+
+	var decodeErrs gocsv.DecodeErrors
+	assert.ErrorAs(t, err, &decodeErrs)
+	assert.Len(t, decodeErrs, 2)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, decodeErrs[1], &parseErr)
+	assert.Equal(t, 2, parseErr.Record)
+	assert.Equal(t, 3, parseErr.Line)
+}
+
+func TestDecodeQuarantine(t *testing.T) {
+	input := "name,age\nJohn,25\nMichael,fifty\nJane,23\n"
+	reader := strings.NewReader(input)
+
+	var quarantine bytes.Buffer
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	decoder.SetQuarantine(func() gocsv.CSVWriter {
+		return csv.NewWriter(&quarantine)
+	})
+	var records []A
+	err := decoder.Decode(&records)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []A{{"John", 25}, {"Jane", 23}}, records)
+
+	rejected, err := csv.NewReader(&quarantine).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, rejected, 2)
+	assert.Equal(t, []string{"name", "age", gocsv.QuarantineErrorColumn}, rejected[0])
+	assert.Equal(t, []string{"Michael", "fifty"}, rejected[1][:2])
+	assert.Contains(t, rejected[1][2], "record 2")
+}
+
+type D struct {
+	Int     int     `csv:"int"`
+	Uint    uint    `csv:"uint"`
+	Uintptr uintptr `csv:"uintptr"`
+	Int8    int8    `csv:"int8"`
+}
+
+func TestDecodeIntegerKinds(t *testing.T) {
+	input := "-1,2,3,-4\n"
+	reader := strings.NewReader(input)
+
+	var records []D
+	assert.Nil(t, gocsv.NewDecoder(reader).Decode(&records))
+
+	assert.Equal(t, []D{{-1, 2, 3, -4}}, records)
+}
+
+func TestDecodeIntegerOverflow(t *testing.T) {
+	input := "1,2,3,128\n"
+	reader := strings.NewReader(input)
+
+	var records []D
+	err := gocsv.NewDecoder(reader).Decode(&records)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, "Int8", parseErr.Field)
+}
+
+type Status string
+
+type Cents int64
+
+type E struct {
+	Status Status  `csv:"status"`
+	Amount Cents   `csv:"amount"`
+	Ratio  float32 `csv:"ratio"`
+	Active bool    `csv:"active"`
+	Notes  *string `csv:"notes"`
+}
+
+func TestDecodeNamedPrimitiveTypes(t *testing.T) {
+	input := "paid,1250,0.5,true,late\n"
+	reader := strings.NewReader(input)
+
+	var records []E
+	assert.Nil(t, gocsv.NewDecoder(reader).Decode(&records))
+
+	notes := "late"
+	assert.Equal(t, []E{{"paid", 1250, 0.5, true, &notes}}, records)
+}
+
+type Level int
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(map[Level]string{1: "low", 2: "high"}[l]), nil
+}
+
+type F struct {
+	Addr  netip.Addr `csv:"addr"`
+	Level Level      `csv:"level"`
+}
+
+func TestDecodeTextUnmarshaler(t *testing.T) {
+	input := "10.0.0.1,high\n::1,low\n"
+	reader := strings.NewReader(input)
+
+	var records []F
+	assert.Nil(t, gocsv.NewDecoder(reader).Decode(&records))
+
+	expected := []F{
+		{netip.MustParseAddr("10.0.0.1"), 2},
+		{netip.MustParseAddr("::1"), 1},
+	}
+	assert.Equal(t, expected, records)
+}
+
+type G struct {
+	Created time.Time     `csv:"created,layout=2006-01-02"`
+	Updated *time.Time    `csv:"updated,layout=2006-01-02 15:04,location=Europe/Madrid"`
+	Expires time.Time     `csv:"expires"`
+	Timeout time.Duration `csv:"timeout"`
+}
+
+func TestDecodeTimeTypes(t *testing.T) {
+	input := "2024-03-01,2024-03-01 10:30,01/02/2024,1m30s\n2024-03-02,,,\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.SetTimeLayout("02/01/2006")
+	var records []G
+	assert.Nil(t, decoder.Decode(&records))
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	assert.Nil(t, err)
+	updated := time.Date(2024, 3, 1, 10, 30, 0, 0, madrid)
+	expected := []G{
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), &updated, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 90 * time.Second},
+		{time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), nil, time.Time{}, 0},
+	}
+	assert.Equal(t, expected, records)
+}
+
+type H struct {
+	Name    string    `csv:"name,required"`
+	Country string    `csv:"country,default=ES"`
+	Score   int       `csv:"score,omitempty"`
+	Date    time.Time `csv:"date,layout='Mon, 02 Jan 2006'"`
+}
+
+func TestDecodeTagOptions(t *testing.T) {
+	input := "name,score,date\nJohn,3,\"Fri, 01 Mar 2024\"\nJane,,\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []H
+	assert.Nil(t, decoder.Decode(&records))
+
+	expected := []H{
+		{"John", "ES", 3, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"Jane", "ES", 0, time.Time{}},
+	}
+	assert.Equal(t, expected, records)
+}
+
+type P struct {
+	License string `csv:"driver's license"`
+	Note    string `csv:"owner's note,default='none, yet'"`
+}
+
+func TestDecodeApostropheInTagName(t *testing.T) {
+	reader := strings.NewReader("driver's license,owner's note\nB,\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []P
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Equal(t, []P{{"B", "none, yet"}}, records)
+}
+
+type Q struct {
+	Name  string `csv:"name"`
+	Count int    `csv:"count,default=abc"`
+}
+
+func TestDecodeInvalidDefaultValue(t *testing.T) {
+	reader := strings.NewReader("name,count\nJohn,\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	var records []Q
+	err := decoder.Decode(&records)
+
+	var parseErr *gocsv.ParseError
+	assert.False(t, errors.As(err, &parseErr))
+	assert.ErrorContains(t, err, `default value "abc" of field Count`)
+	assert.Empty(t, records)
+}
+
+func TestDecodeRequiredField(t *testing.T) {
+	input := "name,score\n,3\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []H
+	assert.ErrorIs(t, decoder.Decode(&records), gocsv.ErrRequiredField)
+}
+
+type I struct {
+	Name string `csv:"name,unknown"`
+}
+
+func TestDecodeUnknownTagOption(t *testing.T) {
+	reader := strings.NewReader("John\n")
+
+	var records []I
+	assert.ErrorContains(t, gocsv.NewDecoder(reader).Decode(&records), `unknown tag option "unknown"`)
+}
+
+type J struct {
+	CustomerName string
+	OrderID      int
+	Total        float64 `csv:"amount"`
+}
+
+func TestDecodeUntaggedFields(t *testing.T) {
+	input := "amount,OrderID,customername\n10.5,1,John\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []J
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Equal(t, []J{{"John", 1, 10.5}}, records)
+}
+
+func TestDecodeNamingStrategy(t *testing.T) {
+	input := "order_id,customer_name\n1,John\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.SetNamingStrategy(gocsv.SnakeCase)
+	var records []J
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Equal(t, []J{{"John", 1, 0}}, records)
+}
+
+func TestDecodeHeaderNormalizer(t *testing.T) {
+	input := " CUSTOMER-NAME ,Order Id,Amount,Comment\nJohn,1,10.5,x\n"
+	reader := strings.NewReader(input)
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.SetNamingStrategy(gocsv.SnakeCase)
+	decoder.SetHeaderNormalizer(gocsv.NormalizeHeader)
+	var records []J
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Equal(t, []J{{"John", 1, 10.5}}, records)
+
+	expected := []gocsv.ColumnBinding{
+		{Column: 0, Header: " CUSTOMER-NAME ", Field: "CustomerName"},
+		{Column: 1, Header: "Order Id", Field: "OrderID"},
+		{Column: 2, Header: "Amount", Field: "Total"},
+		{Column: 3, Header: "Comment", Field: ""},
+	}
+	assert.Equal(t, expected, decoder.ColumnBindings())
+}
+
+func TestNormalizeHeader(t *testing.T) {
+	for _, header := range []string{" Customer Name ", "customer_name", "CUSTOMER-NAME", "Customer.Name"} {
+		assert.Equal(t, "customername", gocsv.NormalizeHeader(header))
+	}
+	// Composed and decomposed forms are equal after normalization
+	assert.Equal(t, gocsv.NormalizeHeader("A\u00f1o"), gocsv.NormalizeHeader("An\u0303o"))
+}
+
+type K struct {
+	Name       string `csv:"name"`
+	PostalCode string `csv:"postal_code,alias=zip|zipcode"`
+}
+
+func TestDecodeHeaderAliases(t *testing.T) {
+	for _, header := range []string{"postal_code", "zip", "ZipCode"} {
+		reader := strings.NewReader("name," + header + "\nJohn,28001\n")
+
+		decoder := gocsv.NewDecoder(reader)
+		decoder.ContainsHeader(true)
+		var records []K
+		assert.Nil(t, decoder.Decode(&records))
+
+		assert.Equal(t, []K{{"John", "28001"}}, records, header)
+	}
+}
+
+func TestDecodeDuplicatedAliases(t *testing.T) {
+	reader := strings.NewReader("zip,name,zipcode\n28001,John,28002\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []K
+	assert.ErrorContains(t, decoder.Decode(&records), "columns 0 (zip) and 2 (zipcode) are both bound to field PostalCode")
+}
+
+func TestDecodeDuplicatedAliasesRetry(t *testing.T) {
+	reader := strings.NewReader("zip,name,zipcode\n28001,John,28002\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var record K
+	for range 2 {
+		assert.ErrorContains(t, decoder.DecodeRecord(&record), "are both bound to field PostalCode")
+	}
+	assert.Zero(t, record)
+}
+
+func TestDecodeDisallowUnknownColumns(t *testing.T) {
+	reader := strings.NewReader("name,age,email\nJohn,25,john@example.com\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.DisallowUnknownColumns(true)
+	var records []A
+	err := decoder.Decode(&records)
+
+	assert.ErrorIs(t, err, gocsv.ErrUnknownColumn)
+	assert.ErrorContains(t, err, "email")
+}
+
+func TestDecodeRequireAllColumns(t *testing.T) {
+	reader := strings.NewReader("name\nJohn\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.RequireAllColumns(true)
+	var records []A
+	err := decoder.Decode(&records)
+
+	assert.ErrorIs(t, err, gocsv.ErrMissingColumn)
+	assert.ErrorContains(t, err, "Age")
+}
+
+func TestDecodeStrictBindingRetry(t *testing.T) {
+	decoder := gocsv.NewDecoder(strings.NewReader("name,age,bogus\nJohn,3,x\n"))
+	decoder.ContainsHeader(true)
+	decoder.DisallowUnknownColumns(true)
+
+	var record A
+	assert.ErrorIs(t, decoder.DecodeRecord(&record), gocsv.ErrUnknownColumn)
+	assert.ErrorIs(t, decoder.DecodeRecord(&record), gocsv.ErrUnknownColumn)
+	assert.Nil(t, decoder.ColumnBindings())
+
+	decoder = gocsv.NewDecoder(strings.NewReader("age\n3\n"))
+	decoder.ContainsHeader(true)
+	decoder.RequireAllColumns(true)
+
+	var records []A
+	assert.ErrorIs(t, decoder.Decode(&records), gocsv.ErrMissingColumn)
+	assert.ErrorIs(t, decoder.Decode(&records), gocsv.ErrMissingColumn)
+	assert.Empty(t, records)
+}
+
+type L struct {
+	Name  string            `csv:"name"`
+	Extra map[string]string `csv:",extra"`
+}
+
+func TestDecodeExtraColumns(t *testing.T) {
+	reader := strings.NewReader("color,name,size\nred,John,M\nblue,Jane,\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	decoder.DisallowUnknownColumns(true)
+	var records []L
+	assert.Nil(t, decoder.Decode(&records))
+
+	expected := []L{
+		{"John", map[string]string{"color": "red", "size": "M"}},
+		{"Jane", map[string]string{"color": "blue", "size": ""}},
+	}
+	assert.Equal(t, expected, records)
+}
+
+type M struct {
+	Name  string         `csv:"name"`
+	Extra map[string]int `csv:",extra"`
+}
+
+func TestDecodeExtraColumnsWrongType(t *testing.T) {
+	reader := strings.NewReader("name\nJohn\n")
+
+	var records []M
+	assert.ErrorContains(t, gocsv.NewDecoder(reader).Decode(&records), "extra field Extra should be map[string]string")
+}
+
+type N struct {
+	Amount float64 `csv:"amount,index=4"`
+	ID     string  `csv:"id,index=1"`
+	Name   string  `csv:"name"`
+}
+
+func TestDecodeColumnIndex(t *testing.T) {
+	reader := strings.NewReader("John,1,x,y,10.5,z\nJane,2,x,y,3,z\n")
+
+	var records []N
+	assert.Nil(t, gocsv.NewDecoder(reader).Decode(&records))
+
+	assert.Equal(t, []N{{10.5, "1", "John"}, {3, "2", "Jane"}}, records)
+}
+
+func TestDecodeColumnIndexDisallowUnknownColumns(t *testing.T) {
+	reader := strings.NewReader("John,1,x,y,10.5\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.DisallowUnknownColumns(true)
+	var records []N
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Equal(t, []N{{10.5, "1", "John"}}, records)
+}
+
+func TestDecodeColumnIndexWithHeader(t *testing.T) {
+	reader := strings.NewReader("name,code,a,b,total\nJohn,1,x,y,10.5\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []N
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Equal(t, []N{{10.5, "1", "John"}}, records)
+}
+
+func TestDecodeColumnIndexOutsideHeader(t *testing.T) {
+	reader := strings.NewReader("name,code,amount\nJohn,1,10.5\n")
+
+	decoder := gocsv.NewDecoder(reader)
+	decoder.ContainsHeader(true)
+	var records []N
+	err := decoder.Decode(&records)
+
+	assert.ErrorContains(t, err, "column index 4 of field Amount is outside the header")
+	assert.Empty(t, records)
+}
+
+func TestDecodeRaggedRows(t *testing.T) {
+	input := "name,age\nJohn,25\nMichael\nJane,23,x\n"
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.ContainsHeader(true)
+	var records []A
+	err := decoder.Decode(&records)
+	assert.ErrorIs(t, err, gocsv.ErrFieldCount)
+	assert.ErrorContains(t, err, "record 2, line 3")
+
+	decoder = gocsv.NewDecoder(strings.NewReader(input))
+	decoder.ContainsHeader(true)
+	decoder.SetShortRowPolicy(gocsv.ShortRowPad)
+	decoder.SetLongRowPolicy(gocsv.LongRowTruncate)
+	assert.Nil(t, decoder.Decode(&records))
+	assert.Equal(t, []A{{"John", 25}, {"Michael", 0}, {"Jane", 23}}, records)
+}
+
+func TestDecodePaddedFieldError(t *testing.T) {
+	input := "id,name,amount,active,date\n1,John\n"
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.ContainsHeader(true)
+	decoder.SetShortRowPolicy(gocsv.ShortRowPad)
+	var records []O
+	err := decoder.Decode(&records)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 3, parseErr.Column)
+	assert.Equal(t, 2, parseErr.Line)
+}
+
+func TestDecodeFieldCountErrorLine(t *testing.T) {
+	input := "name,age\n\"a\nb\",1\nx\n"
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.ContainsHeader(true)
+	var records []A
+	err := decoder.Decode(&records)
+
+	assert.ErrorIs(t, err, gocsv.ErrFieldCount)
+	assert.ErrorContains(t, err, "record 2, line 4")
+}
+
+func TestDecodeLongRowsIntoExtra(t *testing.T) {
+	input := "name,color\nJohn,red,x,y\nJane\n"
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.ContainsHeader(true)
+	decoder.SetShortRowPolicy(gocsv.ShortRowPad)
+	decoder.SetLongRowPolicy(gocsv.LongRowExtra)
+	var records []L
+	assert.Nil(t, decoder.Decode(&records))
+
+	expected := []L{
+		{"John", map[string]string{"color": "red", "2": "x", "3": "y"}},
+		{"Jane", map[string]string{"color": ""}},
+	}
+	assert.Equal(t, expected, records)
+}
+
+func TestDecodeSkipRaggedRows(t *testing.T) {
+	input := "John,25\nMichael\nJane,23\n"
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	var records []A
+	err := decoder.Decode(&records)
+
+	assert.ErrorIs(t, err, gocsv.ErrFieldCount)
+	assert.Equal(t, []A{{"John", 25}, {"Jane", 23}}, records)
+}
+
+func TestDecodeConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var records []C
+			assert.Nil(t, gocsv.NewDecoder(strings.NewReader("John,25,19990112\n")).Decode(&records))
+			assert.Len(t, records, 1)
+		}()
+	}
+	wg.Wait()
+}
+
+type O struct {
+	ID     int64     `csv:"id"`
+	Name   string    `csv:"name"`
+	Amount float64   `csv:"amount"`
+	Active bool      `csv:"active"`
+	Date   time.Time `csv:"date,layout=2006-01-02"`
+}
+
+func benchmarkInput(records int) string {
+	var builder strings.Builder
+	for i := range records {
+		builder.WriteString(strconv.Itoa(i) + ",John Doe,1234.56,true,2024-03-01\n")
+	}
+	return builder.String()
+}
+
+func BenchmarkDecode(b *testing.B) {
+	input := benchmarkInput(1000)
+	b.ReportAllocs()
+	for b.Loop() {
+		var records []O
+		if err := gocsv.NewDecoder(strings.NewReader(input)).Decode(&records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// This reader returns the same record forever
+type repeatReader struct {
+	record []string
+}
+
+func (r *repeatReader) Read() ([]string, error) {
+	return r.record, nil
+}
+
+func (r *repeatReader) ReadAll() ([][]string, error) {
+	return nil, errors.New("unbounded reader")
+}
+
+func TestDecodeRecordDoesNotAllocate(t *testing.T) {
+	decoder := gocsv.NewDecoder(nil)
+	decoder.SetReader(func() gocsv.CSVReader {
+		return &repeatReader{[]string{"1", "John Doe", "1234.56", "true", "2024-03-01"}}
+	})
+
+	var record O
+	assert.Nil(t, decoder.DecodeRecord(&record))
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := decoder.DecodeRecord(&record); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	assert.Zero(t, allocs)
+}
+
+func TestDecodeCurrentLineWithMultilineField(t *testing.T) {
+	decoder := gocsv.NewDecoder(strings.NewReader("name,age\n\"John\nDoe\",25\nJane,23\n"))
+	decoder.ContainsHeader(true)
+
+	var record A
+	assert.Nil(t, decoder.DecodeRecord(&record))
+	assert.Equal(t, 3, decoder.CurrentLine())
+	assert.Nil(t, decoder.DecodeRecord(&record))
+	assert.Equal(t, 4, decoder.CurrentLine())
+}
+
+func TestDecodeRecordWithReuseRecord(t *testing.T) {
+	decoder := gocsv.NewDecoder(strings.NewReader("John,25\nMichael,50\n"))
+	decoder.ReuseRecord(true)
+	decoder.CloneStrings(true)
+
+	var first, second A
+	assert.Nil(t, decoder.DecodeRecord(&first))
+	assert.Nil(t, decoder.DecodeRecord(&second))
+
+	assert.Equal(t, A{"John", 25}, first)
+	assert.Equal(t, A{"Michael", 50}, second)
+}
+
+func TestDecodeRecordWithReuseRecordAndHeader(t *testing.T) {
+	decoder := gocsv.NewDecoder(strings.NewReader("name,age\nJohn,25\nJane,x\n"))
+	decoder.ContainsHeader(true)
+	decoder.ReuseRecord(true)
+
+	var record A
+	assert.Nil(t, decoder.DecodeRecord(&record))
+	err := decoder.DecodeRecord(&record)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, "age", parseErr.Header)
+	assert.Equal(t, []gocsv.ColumnBinding{
+		{Column: 0, Header: "name", Field: "Name"},
+		{Column: 1, Header: "age", Field: "Age"},
+	}, decoder.ColumnBindings())
+}
+
+func BenchmarkDecodeRecordReuse(b *testing.B) {
+	input := benchmarkInput(1000)
+	b.ReportAllocs()
+	for b.Loop() {
+		decoder := gocsv.NewDecoder(strings.NewReader(input))
+		decoder.ReuseRecord(true)
+		var record O
+		for {
+			err := decoder.DecodeRecord(&record)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestDecodeParallel(t *testing.T) {
+	decoder := gocsv.NewDecoder(strings.NewReader(benchmarkInput(1000)))
+	decoder.SetConcurrency(4)
+	var records []*O
+	assert.Nil(t, decoder.Decode(&records))
+
+	assert.Len(t, records, 1000)
+	for i, record := range records {
+		assert.Equal(t, int64(i), record.ID)
+	}
+}
+
+func TestDecodeParallelFirstError(t *testing.T) {
+	input := benchmarkInput(300) + "x,John Doe,1.5,true,2024-03-01\n" + benchmarkInput(1000)
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.SetConcurrency(4)
+	var records []O
+	err := decoder.Decode(&records)
+
+	var parseErr *gocsv.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 301, parseErr.Record)
+	assert.Equal(t, 301, parseErr.Line)
+	assert.Equal(t, "ID", parseErr.Field)
+}
+
+func TestDecodeParallelSkipRecord(t *testing.T) {
+	input := benchmarkInput(300) + "x,John Doe,1.5,true,2024-03-01\n" + benchmarkInput(300)
+
+	decoder := gocsv.NewDecoder(strings.NewReader(input))
+	decoder.SetConcurrency(4)
+	decoder.SetErrorPolicy(gocsv.ErrorSkipRecord)
+	var records []O
+	err := decoder.Decode(&records)
+
+	var decodeErrs gocsv.DecodeErrors
+	assert.ErrorAs(t, err, &decodeErrs)
+	assert.Len(t, decodeErrs, 1)
+	assert.Len(t, records, 600)
+	assert.Equal(t, int64(299), records[299].ID)
+	assert.Equal(t, int64(0), records[300].ID)
+}
+
+func TestDecodeParallelErrorLine(t *testing.T) {
+	input := "name,age\n\"a\nb\",x\n"
+
+	for _, concurrency := range []int{1, 2} {
+		decoder := gocsv.NewDecoder(strings.NewReader(input))
+		decoder.ContainsHeader(true)
+		decoder.SetConcurrency(concurrency)
+		var records []A
+		err := decoder.Decode(&records)
+
+		var parseErr *gocsv.ParseError
+		assert.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, 3, parseErr.Line, concurrency)
+	}
+}
+
+func TestAllParallel(t *testing.T) {
+	i := 0
+	for record, err := range gocsv.All[O](strings.NewReader(benchmarkInput(1000)), func(d *gocsv.Decoder) {
+		d.SetConcurrency(4)
+	}) {
+		assert.Nil(t, err)
+		assert.Equal(t, int64(i), record.ID)
+		if i++; i == 500 {
+			break
+		}
+	}
+
+	assert.Equal(t, 500, i)
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+	input := benchmarkInput(1000)
+	b.ReportAllocs()
+	for b.Loop() {
+		decoder := gocsv.NewDecoder(strings.NewReader(input))
+		decoder.SetConcurrency(4)
+		var records []O
+		if err := decoder.Decode(&records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var records []A
+	err := gocsv.NewDecoder(strings.NewReader("John,25\n")).DecodeContext(ctx, &records)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, records)
+}
+
+func TestDecodeRecordContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	decoder := gocsv.NewDecoder(strings.NewReader("John,25\nMichael,50\n"))
+
+	var record A
+	assert.Nil(t, decoder.DecodeRecordContext(ctx, &record))
+	cancel()
+	err := decoder.DecodeRecordContext(ctx, &record)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "record 1")
+	assert.Equal(t, A{"John", 25}, record)
+}
+
+func TestAllParallelContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	i := 0
+	var err error
+	for _, err = range gocsv.AllContext[O](ctx, strings.NewReader(benchmarkInput(1000)), func(d *gocsv.Decoder) {
+		d.SetConcurrency(4)
+	}) {
+		if err != nil {
+			break
+		}
+		if i++; i == 300 {
+			cancel()
+		}
+	}
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "record 300")
+	assert.Equal(t, 300, i)
+}
+
+func TestDecodeToChan(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		decoder := gocsv.NewDecoder(strings.NewReader(benchmarkInput(1000)))
+		decoder.SetConcurrency(concurrency)
+
+		ch := make(chan *O)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- decoder.DecodeToChan(context.Background(), ch)
+		}()
+
+		i := 0
+		for record := range ch {
+			assert.Equal(t, int64(i), record.ID)
+			i++
+		}
+
+		assert.Nil(t, <-errs)
+		assert.Equal(t, 1000, i)
+	}
+}
+
+func TestDecodeToChanContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan A)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- gocsv.NewDecoder(strings.NewReader("John,25\nMichael,50\n")).DecodeToChan(ctx, ch)
+	}()
+
+	assert.Equal(t, A{"John", 25}, <-ch)
+	cancel()
+
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestDecodeToChanWrongType(t *testing.T) {
+	decoder := gocsv.NewDecoder(strings.NewReader("John,25\n"))
+
+	assert.ErrorContains(t, decoder.DecodeToChan(context.Background(), []A{}), "expected a non nil channel")
+	assert.ErrorContains(t, decoder.DecodeToChan(context.Background(), make(chan int)), "expected inner type to be struct")
+}
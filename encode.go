@@ -1,10 +1,15 @@
 package gocsv
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"maps"
 	"reflect"
+	"slices"
 )
 
 type CSVWriter interface {
@@ -18,11 +23,29 @@ type Marshaler interface {
 	MarshalCSV() (string, error)
 }
 
+// This interface is implemented by CSV writers that buffer
+// their output (by default csv.Writer)
+type csvFlusher interface {
+	Flush()
+	Error() error
+}
+
+var ErrEncoderClosed = errors.New("encode: encoder is closed")
+
 // This is the structure that holds the CSV Encoder data
 type Encoder struct {
-	writer CSVWriter
-	err    error
-	header []string
+	writer        CSVWriter
+	err           error
+	header        []string
+	typeInfo      *typeInfo
+	headerWritten bool
+	timeLayout    string
+	extraKeys     []string
+	columns       []int
+	convOpts      []convOptions
+	naming        NamingStrategy
+	closed        bool
+	currentRecord int
 }
 
 // This function encodes a 'Document' into a CSV file
@@ -38,6 +61,18 @@ func (e *Encoder) SetWriter(create func() CSVWriter) {
 	e.writer = create()
 }
 
+// This function sets the default layout of the time.Time fields
+// that do not define one in their tag
+func (e *Encoder) SetTimeLayout(layout string) {
+	e.timeLayout = layout
+}
+
+// This function sets the naming strategy used to derive the
+// header name of the fields without a csv tag
+func (e *Encoder) SetNamingStrategy(naming NamingStrategy) {
+	e.naming = naming
+}
+
 // This function returns the error of the CSV Encoder
 func (e *Encoder) Error() error {
 	return e.err
@@ -45,6 +80,17 @@ func (e *Encoder) Error() error {
 
 // This function writes a 'Document' to the CSV file
 func (e *Encoder) Encode(records any) error {
+	return e.EncodeContext(context.Background(), records)
+}
+
+// This function writes a 'Document' to the CSV file like Encode. The
+// context is checked before every record, once it is done the encoding
+// stops with its error and nothing is written
+func (e *Encoder) EncodeContext(ctx context.Context, records any) error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+
 	inValue, inType := getInValueAndType(records)
 	if ensureInType(inType) != nil {
 		return fmt.Errorf("encode: wrong type received (%s), expected to receive a slice", inType.Kind())
@@ -59,26 +105,28 @@ func (e *Encoder) Encode(records any) error {
 		return err
 	}
 
-	typeInfo, err := getTypeInfo(inInnerType)
-	if err != nil {
+	if err := e.prepare(inInnerType); err != nil {
 		return err
 	}
 
-	e.header = make([]string, 0, len(typeInfo.fields))
-	for _, field := range typeInfo.fields {
-		e.header = append(e.header, field.fTag)
+	if err := e.prepareExtraColumns(func(yield func(reflect.Value) bool) {
+		for i := range inValue.Len() {
+			if !yield(inValue.Index(i)) {
+				return
+			}
+		}
+	}); err != nil {
+		return err
 	}
 
 	lines := make([][]string, 0, inValue.Len())
 	for i := range inValue.Len() {
-		record := inValue.Index(i)
-		line := make([]string, 0, len(typeInfo.fields))
-		for _, fieldInfo := range typeInfo.fields {
-			val, err := toString(record.FieldByIndex(fieldInfo.index).Interface())
-			if err != nil {
-				return err
-			}
-			line = append(line, val)
+		if err := e.checkContext(ctx, e.currentRecord+i); err != nil {
+			return err
+		}
+		line, err := e.encodeLine(inValue.Index(i))
+		if err != nil {
+			return err
 		}
 		lines = append(lines, line)
 	}
@@ -91,15 +139,260 @@ func (e *Encoder) Encode(records any) error {
 		return err
 	}
 
+	e.currentRecord += len(lines)
 	return nil
 }
 
+// This function writes a single record to the CSV file. The record
+// should be a struct or a pointer to a struct. The header is written
+// before the first record. The output may be buffered until Flush
+// or Close are called
+func (e *Encoder) EncodeRecord(record any) error {
+	return e.EncodeRecordContext(context.Background(), record)
+}
+
+// This function writes a single record to the CSV file like
+// EncodeRecord. When the context is done it returns its error instead
+func (e *Encoder) EncodeRecordContext(ctx context.Context, record any) error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+
+	inValue := reflect.ValueOf(record)
+	if inValue.Kind() == reflect.Pointer {
+		if inValue.IsNil() {
+			return errors.New("encode: received a nil record")
+		}
+		inValue = inValue.Elem()
+	}
+
+	if err := ensureInInnerType(inValue.Type()); err != nil {
+		return err
+	}
+
+	return e.encodeRecord(ctx, inValue)
+}
+
+// This function receives the records of the passed channel and writes
+// them to the CSV file until it is closed, then the Encoder is flushed.
+// The channel should be a chan T or a chan *T where T is a struct. When
+// the context is done the encoding stops with its error
+func (e *Encoder) EncodeFromChan(ctx context.Context, ch any) error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+
+	chVal := reflect.ValueOf(ch)
+	if chVal.Kind() != reflect.Chan || chVal.IsNil() || chVal.Type().ChanDir()&reflect.RecvDir == 0 {
+		return fmt.Errorf("encode: expected a non nil channel to receive the records (%T)", ch)
+	}
+
+	inInnerType := getInInnerType(chVal.Type())
+	if inInnerType.Kind() == reflect.Pointer {
+		inInnerType = inInnerType.Elem()
+	}
+	if err := ensureInInnerType(inInnerType); err != nil {
+		return err
+	}
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: chVal},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	}
+	for {
+		chosen, record, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return e.checkContext(ctx, e.currentRecord)
+		}
+		if !ok {
+			return e.Flush()
+		}
+
+		if record.Kind() == reflect.Pointer {
+			if record.IsNil() {
+				return errors.New("encode: received a nil record")
+			}
+			record = record.Elem()
+		}
+		if err := e.encodeRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+}
+
+// This function writes a single record, which should be a struct
+func (e *Encoder) encodeRecord(ctx context.Context, inValue reflect.Value) error {
+	if err := e.checkContext(ctx, e.currentRecord); err != nil {
+		return err
+	}
+
+	if err := e.prepare(inValue.Type()); err != nil {
+		return err
+	}
+
+	if err := e.prepareExtraColumns(slices.Values([]reflect.Value{inValue})); err != nil {
+		return err
+	}
+
+	line, err := e.encodeLine(inValue)
+	if err != nil {
+		return err
+	}
+
+	if err := e.encodeHeader(); err != nil {
+		return err
+	}
+
+	if err := e.setError(e.writer.Write(line)); err != nil {
+		return err
+	}
+
+	e.currentRecord++
+	return nil
+}
+
+// This function writes any buffered data to the underlying writer
+// and returns the error of the CSV writer, if any
+func (e *Encoder) Flush() error {
+	if flusher, ok := e.writer.(csvFlusher); ok {
+		flusher.Flush()
+		return e.setError(flusher.Error())
+	}
+
+	return e.err
+}
+
+// This function flushes the Encoder and prevents further writes
+func (e *Encoder) Close() error {
+	if e.closed {
+		return e.err
+	}
+
+	e.closed = true
+	return e.Flush()
+}
+
+// This function computes the type information and the header
+// for the given type. It is done once per Encoder, subsequent
+// calls only check that the type has not changed
+func (e *Encoder) prepare(typ reflect.Type) error {
+	if e.typeInfo != nil {
+		if e.typeInfo.parentType != typ {
+			return fmt.Errorf("encode: type %s differs from previously encoded type %s", typ.String(), e.typeInfo.parentType.String())
+		}
+		return nil
+	}
+
+	typeInfo, err := getTypeInfo(typ)
+	if err != nil {
+		return err
+	}
+
+	e.convOpts = typeInfo.convOptions(convOptions{layout: e.timeLayout})
+	e.columns = typeInfo.columnLayout()
+	e.header = typeInfo.layoutHeader(e.columns, e.naming)
+
+	e.typeInfo = typeInfo
+	return nil
+}
+
+// This function appends the keys of the extra field of the records
+// to the header, in lexical order. It is done before writing the
+// header, afterwards the extra columns can not change
+func (e *Encoder) prepareExtraColumns(records iter.Seq[reflect.Value]) error {
+	if e.typeInfo.extra == nil || e.headerWritten {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	for record := range records {
+		for _, key := range record.FieldByIndex(e.typeInfo.extra.index).MapKeys() {
+			keys[key.String()] = true
+		}
+	}
+
+	e.extraKeys = slices.Sorted(maps.Keys(keys))
+	for _, key := range e.extraKeys {
+		if slices.Contains(e.header, key) {
+			return fmt.Errorf("encode: extra column %q conflicts with a field", key)
+		}
+	}
+
+	e.header = append(e.header[:len(e.columns):len(e.columns)], e.extraKeys...)
+	return nil
+}
+
+// This function converts every field of a record to a CSV line
+func (e *Encoder) encodeLine(record reflect.Value) ([]string, error) {
+	line := make([]string, 0, len(e.header))
+	for _, i := range e.columns {
+		// Columns without a field are left empty
+		if i < 0 {
+			line = append(line, "")
+			continue
+		}
+		fieldInfo := &e.typeInfo.fields[i]
+		fieldVal := record.FieldByIndex(fieldInfo.index)
+		if fieldInfo.omitEmpty && fieldVal.IsZero() {
+			line = append(line, "")
+			continue
+		}
+		val, err := fieldInfo.encode(fieldVal, &e.convOpts[i])
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, val)
+	}
+
+	if e.typeInfo.extra != nil {
+		extra := record.FieldByIndex(e.typeInfo.extra.index).Interface().(map[string]string)
+		for key := range extra {
+			if _, found := slices.BinarySearch(e.extraKeys, key); !found {
+				return nil, fmt.Errorf("encode: extra column %q is not in the header", key)
+			}
+		}
+		for _, key := range e.extraKeys {
+			line = append(line, extra[key])
+		}
+	}
+
+	return line, nil
+}
+
+// This function returns the error of the context once it is done,
+// along with the number of the last record written
+func (e *Encoder) checkContext(ctx context.Context, record int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("encode: record %d: %w", record, err)
+	}
+	return nil
+}
+
+// This function writes the header, only the first time it is called
 func (e *Encoder) encodeHeader() error {
-	return e.writer.Write(e.header)
+	if e.headerWritten {
+		return nil
+	}
+
+	if err := e.setError(e.writer.Write(e.header)); err != nil {
+		return err
+	}
+
+	e.headerWritten = true
+	return nil
 }
 
 func (e *Encoder) encodeContent(lines [][]string) error {
-	return e.writer.WriteAll(lines)
+	return e.setError(e.writer.WriteAll(lines))
+}
+
+// This function stores the first error of the CSV writer
+func (e *Encoder) setError(err error) error {
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+
+	return err
 }
 
 // This function returns the in data structure value and type
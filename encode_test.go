@@ -2,7 +2,10 @@ package gocsv_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
+	"net/netip"
 	"testing"
 	"time"
 
@@ -60,3 +63,221 @@ func TestEncodeWithCustomType(t *testing.T) {
 
 	assert.Equal(t, expected, buffer.String())
 }
+
+func TestEncodeRecord(t *testing.T) {
+	expected := "Name,Age\nJohn,25\nMichael,43\nJane,23\n"
+
+	var buffer bytes.Buffer
+	encoder := gocsv.NewEncoder(&buffer)
+	assert.Nil(t, encoder.EncodeRecord(A{Name: "John", Age: 25}))
+	assert.Nil(t, encoder.EncodeRecord(&A{Name: "Michael", Age: 43}))
+	assert.Nil(t, encoder.Encode([]A{{Name: "Jane", Age: 23}}))
+	assert.Nil(t, encoder.Close())
+
+	assert.Equal(t, expected, buffer.String())
+	assert.ErrorIs(t, encoder.EncodeRecord(A{}), gocsv.ErrEncoderClosed)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestEncodeRecordWriterError(t *testing.T) {
+	encoder := gocsv.NewEncoder(failingWriter{})
+	assert.Nil(t, encoder.EncodeRecord(A{Name: "John", Age: 25}))
+	assert.NotNil(t, encoder.Flush())
+	assert.EqualError(t, encoder.Error(), "write failed")
+}
+
+func TestEncodeIntegerKinds(t *testing.T) {
+	expected := "int,uint,uintptr,int8\n-1,2,3,-4\n"
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode([]D{{-1, 2, 3, -4}}))
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestEncodeNamedPrimitiveTypes(t *testing.T) {
+	expected := "status,amount,ratio,active,notes\npaid,1250,0.5,true,\n"
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode([]E{{"paid", 1250, 0.5, true, nil}}))
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestEncodeTextMarshaler(t *testing.T) {
+	expected := "addr,level\n10.0.0.1,high\n"
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode([]F{{netip.MustParseAddr("10.0.0.1"), 2}}))
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestEncodeTimeTypes(t *testing.T) {
+	expected := "created,updated,expires,timeout\n2024-03-01,2024-03-01 10:30,2024-02-01T00:00:00Z,1m30s\n2024-03-02,,,0s\n"
+
+	updated := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	decoded := []G{
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), &updated, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 90 * time.Second},
+		{time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), nil, time.Time{}, 0},
+	}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode(decoded))
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+type R struct {
+	Local time.Time `csv:"local,layout=2006-01-02 15:04"`
+	UTC   time.Time `csv:"utc,location=UTC"`
+}
+
+func TestEncodeTimeInOwnLocation(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	assert.Nil(t, err)
+	date := time.Date(2024, 6, 1, 10, 0, 0, 0, madrid)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode([]R{{date, date}}))
+
+	assert.Equal(t, "local,utc\n2024-06-01 10:00,2024-06-01T08:00:00Z\n", buffer.String())
+}
+
+func TestEncodeTagOptions(t *testing.T) {
+	expected := "name,country,score,date\nJohn,ES,3,\"Fri, 01 Mar 2024\"\nJane,,,\n"
+	decoded := []H{
+		{"John", "ES", 3, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"Jane", "", 0, time.Time{}},
+	}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode(decoded))
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestEncodeNamingStrategy(t *testing.T) {
+	records := []J{{"John", 1, 10.5}}
+
+	for naming, expected := range map[string]string{
+		"none":  "CustomerName,OrderID,amount\nJohn,1,10.5\n",
+		"snake": "customer_name,order_id,amount\nJohn,1,10.5\n",
+		"kebab": "customer-name,order-id,amount\nJohn,1,10.5\n",
+		"camel": "customerName,orderId,amount\nJohn,1,10.5\n",
+	} {
+		var buffer bytes.Buffer
+		encoder := gocsv.NewEncoder(&buffer)
+		switch naming {
+		case "snake":
+			encoder.SetNamingStrategy(gocsv.SnakeCase)
+		case "kebab":
+			encoder.SetNamingStrategy(gocsv.KebabCase)
+		case "camel":
+			encoder.SetNamingStrategy(gocsv.CamelCase)
+		}
+		assert.Nil(t, encoder.Encode(records))
+		assert.Equal(t, expected, buffer.String(), naming)
+	}
+}
+
+func TestEncodeExtraColumns(t *testing.T) {
+	expected := "name,color,size\nJohn,red,M\nJane,blue,\n"
+	decoded := []L{
+		{"John", map[string]string{"size": "M", "color": "red"}},
+		{"Jane", map[string]string{"color": "blue"}},
+	}
+
+	var buffer bytes.Buffer
+	encoder := gocsv.NewEncoder(&buffer)
+	assert.Nil(t, encoder.Encode(decoded))
+	assert.ErrorContains(t, encoder.EncodeRecord(L{"Doe", map[string]string{"weight": "80"}}), `extra column "weight" is not in the header`)
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestEncodeColumnIndex(t *testing.T) {
+	expected := "name,id,,,amount\nJohn,1,,,10.5\n"
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).Encode([]N{{10.5, "1", "John"}}))
+
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestEncodeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buffer bytes.Buffer
+	encoder := gocsv.NewEncoder(&buffer)
+	assert.ErrorIs(t, encoder.EncodeContext(ctx, []A{{"John", 25}}), context.Canceled)
+	assert.Nil(t, encoder.Close())
+
+	assert.Empty(t, buffer.String())
+}
+
+func TestEncodeRecordContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var buffer bytes.Buffer
+	encoder := gocsv.NewEncoder(&buffer)
+	assert.Nil(t, encoder.EncodeRecordContext(ctx, A{"John", 25}))
+	cancel()
+	err := encoder.EncodeRecordContext(ctx, A{"Michael", 50})
+	assert.Nil(t, encoder.Close())
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "record 1")
+	assert.Equal(t, "Name,Age\nJohn,25\n", buffer.String())
+}
+
+func TestEncodeFromChan(t *testing.T) {
+	ch := make(chan *A, 2)
+	ch <- &A{"John", 25}
+	ch <- &A{"Michael", 50}
+	close(ch)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gocsv.NewEncoder(&buffer).EncodeFromChan(context.Background(), ch))
+
+	assert.Equal(t, "Name,Age\nJohn,25\nMichael,50\n", buffer.String())
+}
+
+func TestEncodeFromChanContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buffer bytes.Buffer
+	err := gocsv.NewEncoder(&buffer).EncodeFromChan(ctx, make(chan A))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, buffer.String())
+}
+
+func TestEncodeFromChanWrongType(t *testing.T) {
+	encoder := gocsv.NewEncoder(&bytes.Buffer{})
+
+	assert.ErrorContains(t, encoder.EncodeFromChan(context.Background(), []A{}), "expected a non nil channel")
+	assert.ErrorContains(t, encoder.EncodeFromChan(context.Background(), make(chan string)), "unexpected inner type")
+}
+
+func BenchmarkEncode(b *testing.B) {
+	records := make([]O, 1000)
+	for i := range records {
+		records[i] = O{int64(i), "John Doe", 1234.56, true, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		var buffer bytes.Buffer
+		if err := gocsv.NewEncoder(&buffer).Encode(records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
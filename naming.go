@@ -0,0 +1,86 @@
+package gocsv
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// This type defines how the name of a field without a csv tag
+// is converted to a header name
+type NamingStrategy func(fieldName string) string
+
+// This function converts a field name to snake_case (e.g. UserID -> user_id)
+func SnakeCase(fieldName string) string {
+	return strings.Join(lowerWords(fieldName), "_")
+}
+
+// This function converts a field name to kebab-case (e.g. UserID -> user-id)
+func KebabCase(fieldName string) string {
+	return strings.Join(lowerWords(fieldName), "-")
+}
+
+// This function converts a field name to camelCase (e.g. UserID -> userId)
+func CamelCase(fieldName string) string {
+	words := lowerWords(fieldName)
+	for i := 1; i < len(words); i++ {
+		runes := []rune(words[i])
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
+
+// This function splits a field name into lower case words. A new word
+// starts on every upper case letter that follows a lower case letter or
+// a digit, and on the last upper case letter of an acronym (HTTPServer ->
+// http, server). Underscores and dashes are treated as separators
+func lowerWords(fieldName string) []string {
+	runes := []rune(fieldName)
+	words := make([]string, 0, 2)
+	start := 0
+	for i, r := range runes {
+		if r == '_' || r == '-' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := runes[i-1]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// This type defines a function that normalizes the header names
+// of the document and the fields before matching them
+type HeaderNormalizer func(name string) string
+
+// This function is a HeaderNormalizer that applies the Unicode NFC
+// normalization, folds the case and removes every space and punctuation
+// character (e.g. " Customer Name ", "customer_name" and "CUSTOMER-NAME"
+// are normalized to "customername")
+func NormalizeHeader(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, norm.NFC.String(name))
+}
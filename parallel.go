@@ -0,0 +1,238 @@
+package gocsv
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// The number of records converted together by a goroutine
+const parallelBatchSize = 256
+
+// This type holds a record read by the parallel pipeline
+// along with the result of its conversion
+type parallelRecord struct {
+	line      []string
+	pos       recordPos
+	value     reflect.Value
+	fieldErrs []error
+	err       error
+}
+
+// This type holds a batch of consecutive records and its
+// position in the document
+type parallelBatch struct {
+	seq     int
+	records []parallelRecord
+}
+
+// This function sets the number of goroutines that convert the records
+// in Decode and All. The records are read in batches which are converted
+// concurrently, keeping their order in the output. Values lower than 2
+// decode sequentially. DecodeRecord always decodes sequentially
+func (d *Decoder) SetConcurrency(n int) {
+	d.concurrency = n
+}
+
+// This function reads the records in batches and converts them in
+// d.concurrency goroutines. The converted records are passed to emit in
+// the order of the document from the calling goroutine, where the errors
+// are handled. The first error that stops the decoding cancels the rest
+// of the work, as does a false result of emit
+func (d *Decoder) decodeParallel(ctx context.Context, wasInnerPointer bool, emit func(record reflect.Value) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan parallelBatch, d.concurrency)
+	results := make(chan parallelBatch, d.concurrency)
+	// The batches read ahead of the next one to emit are limited
+	window := make(chan struct{}, 2*d.concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(batches)
+		d.readBatches(ctx, batches, window)
+	}()
+
+	for range d.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				d.convertBatch(batch, wasInnerPointer)
+				select {
+				case results <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	err := d.emitBatches(ctx, results, window, emit)
+	cancel()
+	// Wait for every goroutine before returning
+	for range results {
+	}
+
+	return err
+}
+
+// This function reads the records of the document in batches until
+// the end of the file, a read error that stops the decoding or the
+// cancellation of the context
+func (d *Decoder) readBatches(ctx context.Context, batches chan<- parallelBatch, window chan<- struct{}) {
+	for seq := 0; ; seq++ {
+		select {
+		case window <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		batch := parallelBatch{seq: seq, records: make([]parallelRecord, 0, parallelBatchSize)}
+		done := false
+		for len(batch.records) < parallelBatchSize {
+			line, err := d.readLine()
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+
+			record := parallelRecord{line: slices.Clone(line), pos: recordPos{record: d.currentRecord, line: d.currentLine}, err: err}
+			if err == nil {
+				record.pos = d.currentPos(line)
+				// The reader reports the positions of the last record only
+				record.pos.current = false
+				if positioner, ok := d.reader.(fieldPositioner); ok {
+					record.pos.fieldLines = make([]int, len(line))
+					for j := range line {
+						record.pos.fieldLines[j], _ = positioner.FieldPos(j)
+					}
+				}
+			}
+			batch.records = append(batch.records, record)
+			if err != nil && !isRecoverable(err) {
+				done = true
+				break
+			}
+		}
+
+		if len(batch.records) > 0 {
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// This function converts every record of a batch into a new value
+func (d *Decoder) convertBatch(batch parallelBatch, wasInnerPointer bool) {
+	for k := range batch.records {
+		record := &batch.records[k]
+		if record.err != nil {
+			continue
+		}
+
+		record.value = getNewOutInnerValue(wasInnerPointer, d.typeInfo.parentType)
+		out := record.value
+		if wasInnerPointer {
+			out = out.Elem()
+		}
+		record.fieldErrs, record.err = d.decodeLine(record.line, out, record.pos)
+	}
+}
+
+// This function emits the converted records in the order of the
+// document and handles their errors according to the error policy
+func (d *Decoder) emitBatches(ctx context.Context, results <-chan parallelBatch, window <-chan struct{}, emit func(record reflect.Value) bool) error {
+	pending := make(map[int]parallelBatch)
+	next := 0
+	last := 0
+	for batch := range results {
+		pending[batch.seq] = batch
+		for {
+			batch, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			for _, record := range batch.records {
+				if err := d.checkContext(ctx, last); err != nil {
+					return err
+				}
+				last = record.pos.record
+
+				if record.err != nil {
+					if err := d.rejectLine(record.line, record.err); err != nil {
+						return err
+					}
+					continue
+				}
+				// The record is kept with the fields that failed set to zero
+				if len(record.fieldErrs) > 0 {
+					if err := d.rejectLine(record.line, record.fieldErrs...); err != nil {
+						return err
+					}
+				}
+				if !emit(record.value) {
+					return nil
+				}
+			}
+		}
+	}
+
+	return d.checkContext(ctx, last)
+}
+
+// This function decodes the records of an iterator with the parallel
+// pipeline of the Decoder, see All
+func allParallel[T any](ctx context.Context, d *Decoder, yield func(T, error) bool) {
+	typ := reflect.TypeFor[T]()
+	wasInnerPointer := typ.Kind() == reflect.Pointer
+	if wasInnerPointer {
+		typ = typ.Elem()
+	}
+
+	err := ensureOutInnerType(typ)
+	if err == nil {
+		err = d.prepare(typ)
+	}
+
+	stopped := false
+	if err == nil {
+		err = d.decodeEach(ctx, wasInnerPointer, func(record reflect.Value) bool {
+			stopped = !yield(record.Interface().(T), nil)
+			return !stopped
+		})
+	}
+	if stopped {
+		return
+	}
+
+	// Report the errors collected by a lenient Decoder
+	if err == nil {
+		err = d.Error()
+	}
+	if err != nil {
+		var zero T
+		yield(zero, err)
+	}
+}
@@ -1,8 +1,14 @@
 package gocsv
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // The thag that the elements of the struct may contain
@@ -12,6 +18,8 @@ var tagName string = "csv"
 type typeInfo struct {
 	parentType reflect.Type
 	fields     []fieldInfo
+	// The field that captures the columns not bound to other fields
+	extra *fieldInfo
 }
 
 // This type will contain the information of a given type
@@ -19,12 +27,63 @@ type fieldInfo struct {
 	index []int
 	fName string
 	fTag  string
+	tagOptions
+	// The conversions of the field type, chosen once per type
+	decode decodeFunc
+	encode encodeFunc
 }
 
+// This type will contain the options of the tag of a field
+type tagOptions struct {
+	omitEmpty    bool
+	required     bool
+	inline       bool
+	extra        bool
+	hasIndex     bool
+	columnIndex  int
+	hasDefault   bool
+	defaultValue string
+	layout       string
+	location     *time.Location
+	aliases      []string
+}
+
+// This variable holds the type of the extra columns field
+var extraMapType reflect.Type = reflect.TypeOf(map[string]string(nil))
+
 // This variable holds the Unmarshaler interface type
 var unmarshalerType reflect.Type = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
 
+// These variables hold the rest of interface types that
+// make a struct be converted as a single field
+var (
+	marshalerType       reflect.Type = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textUnmarshalerType reflect.Type = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   reflect.Type = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// This variable caches the computed *typeInfo of every type
+var typeInfoCache sync.Map
+
+// This function returns the type information of a struct type. It is
+// computed once per type and shared by every Decoder and Encoder, so
+// it should not be modified
 func getTypeInfo(t reflect.Type) (*typeInfo, error) {
+	if tInfo, ok := typeInfoCache.Load(t); ok {
+		return tInfo.(*typeInfo), nil
+	}
+
+	tInfo, err := buildTypeInfo(t)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := typeInfoCache.LoadOrStore(t, tInfo)
+	return cached.(*typeInfo), nil
+}
+
+// This function computes the type information of a struct type
+func buildTypeInfo(t reflect.Type) (*typeInfo, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("type %s (%s) is not a struct", t.String(), t.Kind())
 	}
@@ -40,13 +99,19 @@ func getTypeInfo(t reflect.Type) (*typeInfo, error) {
 		if (tField.PkgPath != "" && !tField.Anonymous) || tField.Tag.Get(tagName) == "-" {
 			continue
 		}
-		fKind := tField.Type.Kind()
-		// If embedded struct extract its fields
-		if fKind == reflect.Struct {
-			// Check if the struct implements the Unmarshaler interface
-			if reflect.New(t.FieldByIndex(tField.Index).Type).Type().Implements(unmarshalerType) {
-				goto INSERT
+		fInfo, err := getStructFieldInfo(tField)
+		if err != nil {
+			return nil, err
+		}
+		if fInfo.extra {
+			if err := setExtraField(&tInfo, fInfo, tField.Type); err != nil {
+				return nil, err
 			}
+			continue
+		}
+		// If embedded struct extract its fields, unless it implements
+		// a marshaling interface and it is not explicitly inlined
+		if tField.Type.Kind() == reflect.Struct && (fInfo.inline || !isCustomType(tField.Type)) {
 			embeddedInfo, err := getTypeInfo(tField.Type)
 			if err != nil {
 				return nil, err
@@ -57,19 +122,132 @@ func getTypeInfo(t reflect.Type) (*typeInfo, error) {
 					return nil, err
 				}
 			}
+			if embeddedInfo.extra != nil {
+				extraInfo := *embeddedInfo.extra
+				extraInfo.index = append([]int{i}, extraInfo.index...)
+				if err := setExtraField(&tInfo, &extraInfo, extraMapType); err != nil {
+					return nil, err
+				}
+			}
 			continue
 		}
-	INSERT:
-		fInfo, err := getStructFieldInfo(tField)
-		if err != nil {
-			return nil, err
-		}
 		tInfo.fields = append(tInfo.fields, *fInfo)
 	}
 
+	// Two fields can not be bound to the same column index
+	indexes := make(map[int]string)
+	for _, field := range tInfo.fields {
+		if !field.hasIndex {
+			continue
+		}
+		if name, ok := indexes[field.columnIndex]; ok {
+			return nil, fmt.Errorf("field %s and %s have the same column index %d", name, field.fName, field.columnIndex)
+		}
+		indexes[field.columnIndex] = field.fName
+	}
+
 	return &tInfo, nil
 }
 
+// This function sets the field that captures the extra columns,
+// which should be unique and of type map[string]string
+func setExtraField(tInfo *typeInfo, fInfo *fieldInfo, t reflect.Type) error {
+	if t != extraMapType {
+		return fmt.Errorf("extra field %s should be %s (%s)", fInfo.fName, extraMapType.String(), t.String())
+	}
+
+	if tInfo.extra != nil {
+		return fmt.Errorf("extra field %s conflicts with %s", fInfo.fName, tInfo.extra.fName)
+	}
+
+	tInfo.extra = fInfo
+	return nil
+}
+
+// This function returns true if a pointer to the type implements
+// one of the Marshaler, Unmarshaler or encoding.Text* interfaces
+func isCustomType(t reflect.Type) bool {
+	ptrType := reflect.PointerTo(t)
+	return ptrType.Implements(unmarshalerType) || ptrType.Implements(marshalerType) ||
+		ptrType.Implements(textUnmarshalerType) || ptrType.Implements(textMarshalerType)
+}
+
+// This function returns the header name of the field. Fields without
+// a tag name use their field name, converted by the naming strategy
+func (f *fieldInfo) headerName(naming NamingStrategy) string {
+	if f.fTag != "" {
+		return f.fTag
+	}
+	if naming != nil {
+		return naming(f.fName)
+	}
+	return f.fName
+}
+
+// This function returns the header names of every field
+func (t *typeInfo) headerNames(naming NamingStrategy) []string {
+	names := make([]string, 0, len(t.fields))
+	for i := range t.fields {
+		names = append(names, t.fields[i].headerName(naming))
+	}
+	return names
+}
+
+// This function returns the field bound to every column when the
+// columns are laid out by position. Fields with an explicit index take
+// that column and the rest fill the free columns in the field order.
+// The columns that are not bound to any field hold -1
+func (t *typeInfo) columnLayout() []int {
+	length := len(t.fields)
+	for _, field := range t.fields {
+		if field.hasIndex {
+			length = max(length, field.columnIndex+1)
+		}
+	}
+
+	columns := make([]int, length)
+	for j := range columns {
+		columns[j] = -1
+	}
+	for i, field := range t.fields {
+		if field.hasIndex {
+			columns[field.columnIndex] = i
+		}
+	}
+
+	j := 0
+	for i, field := range t.fields {
+		if field.hasIndex {
+			continue
+		}
+		for columns[j] >= 0 {
+			j++
+		}
+		columns[j] = i
+	}
+
+	return columns
+}
+
+// This function returns true if any field has an explicit column index
+func (t *typeInfo) hasColumnIndex() bool {
+	return slices.ContainsFunc(t.fields, func(field fieldInfo) bool { return field.hasIndex })
+}
+
+// This function returns the header names of a column layout, the
+// columns that are not bound to any field have an empty name
+func (t *typeInfo) layoutHeader(columns []int, naming NamingStrategy) []string {
+	header := make([]string, 0, len(columns))
+	for _, i := range columns {
+		name := ""
+		if i >= 0 {
+			name = t.fields[i].headerName(naming)
+		}
+		header = append(header, name)
+	}
+	return header
+}
+
 func addFieldInfo(t reflect.Type, tInfo *typeInfo, newField *fieldInfo) error {
 	for _, field := range tInfo.fields {
 		// Return the first error
@@ -85,8 +263,118 @@ func addFieldInfo(t reflect.Type, tInfo *typeInfo, newField *fieldInfo) error {
 }
 
 func getStructFieldInfo(f reflect.StructField) (*fieldInfo, error) {
+	name, options, err := parseTag(f.Tag.Get(tagName))
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", f.Name, err)
+	}
+
 	fInfo := &fieldInfo{
-		index: f.Index, fName: f.Name, fTag: f.Tag.Get(tagName)}
+		index: f.Index, fName: f.Name, fTag: name, tagOptions: options,
+		decode: newDecodeFunc(f.Type), encode: newEncodeFunc(f.Type)}
 
 	return fInfo, nil
 }
+
+// This function parses a tag with the format "name,option,key=value".
+// The values may be wrapped in single quotes to contain commas
+func parseTag(tag string) (name string, options tagOptions, err error) {
+	parts, err := splitTag(tag)
+	if err != nil {
+		return "", options, err
+	}
+
+	name = parts[0]
+	seen := make(map[string]bool, len(parts)-1)
+	for _, part := range parts[1:] {
+		key, value, hasValue := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if seen[key] {
+			return "", options, fmt.Errorf("duplicated tag option %q", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "omitempty", "required", "inline", "extra":
+			if hasValue {
+				return "", options, fmt.Errorf("tag option %q does not accept a value", key)
+			}
+		case "default", "layout", "location", "alias", "index":
+			if !hasValue {
+				return "", options, fmt.Errorf("tag option %q requires a value", key)
+			}
+			value = unquoteTagValue(value)
+		default:
+			return "", options, fmt.Errorf("unknown tag option %q", key)
+		}
+
+		switch key {
+		case "omitempty":
+			options.omitEmpty = true
+		case "required":
+			options.required = true
+		case "inline":
+			options.inline = true
+		case "extra":
+			options.extra = true
+		case "default":
+			options.hasDefault = true
+			options.defaultValue = value
+		case "layout":
+			options.layout = value
+		case "index":
+			options.hasIndex = true
+			if options.columnIndex, err = strconv.Atoi(value); err != nil || options.columnIndex < 0 {
+				return "", options, fmt.Errorf("invalid column index %q", value)
+			}
+		case "alias":
+			options.aliases = strings.Split(value, "|")
+			if slices.Contains(options.aliases, "") {
+				return "", options, fmt.Errorf("empty alias in %q", value)
+			}
+		case "location":
+			if options.location, err = time.LoadLocation(value); err != nil {
+				return "", options, err
+			}
+		}
+	}
+
+	if options.required && options.hasDefault {
+		return "", options, fmt.Errorf("tag options %q and %q are exclusive", "required", "default")
+	}
+
+	return name, options, nil
+}
+
+// This function splits a tag by the commas that are not inside a
+// single quoted value. A quote only starts a value right after the
+// "key=" of an option, so the name may contain apostrophes
+func splitTag(tag string) ([]string, error) {
+	parts := make([]string, 0, 1)
+	quoted := false
+	start := 0
+	for i, r := range tag {
+		switch {
+		case r == '\'' && quoted:
+			quoted = false
+		case r == '\'' && len(parts) > 0 && strings.IndexByte(tag[start:], '=') == i-start-1:
+			quoted = true
+		case r == ',' && !quoted:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in tag %q", tag)
+	}
+
+	return append(parts, tag[start:]), nil
+}
+
+// This function removes the single quotes wrapping a tag value
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
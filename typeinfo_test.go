@@ -0,0 +1,47 @@
+package gocsv
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedRecord struct {
+	Name string `csv:"name"`
+	Age  int    `csv:"age"`
+}
+
+type concurrentRecord struct {
+	Name string `csv:"name"`
+}
+
+func TestGetTypeInfoIsCached(t *testing.T) {
+	first, err := getTypeInfo(reflect.TypeFor[cachedRecord]())
+	assert.Nil(t, err)
+	second, err := getTypeInfo(reflect.TypeFor[cachedRecord]())
+	assert.Nil(t, err)
+
+	assert.Same(t, first, second)
+}
+
+func TestGetTypeInfoIsCachedConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	results := make([]*typeInfo, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tInfo, err := getTypeInfo(reflect.TypeFor[concurrentRecord]())
+			assert.Nil(t, err)
+			results[i] = tInfo
+		}()
+	}
+	wg.Wait()
+
+	cached, ok := typeInfoCache.Load(reflect.TypeFor[concurrentRecord]())
+	assert.True(t, ok)
+	assert.Same(t, results[0], results[1])
+	assert.Same(t, cached, results[0])
+}
@@ -1,10 +1,12 @@
 package gocsv
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -15,173 +17,333 @@ const (
 	CarriageReturn = "\r"
 )
 
-func setValue(value reflect.Value, valStr string) error {
-	if value.Kind() == reflect.Pointer {
-		value = value.Elem()
-	}
+// The layout used for time.Time fields when none is configured
+const DefaultTimeLayout = time.RFC3339
 
-	switch value.Interface().(type) {
-	case string:
-		val, err := toString(valStr)
-		if err != nil {
-			return err
+var (
+	timeType     reflect.Type = reflect.TypeOf(time.Time{})
+	durationType reflect.Type = reflect.TypeOf(time.Duration(0))
+)
+
+// This structure holds the options used to convert a field
+type convOptions struct {
+	layout string
+	// The location is nil when it is not set, the times are
+	// decoded in UTC and encoded in their own location
+	location     *time.Location
+	cloneStrings bool
+}
+
+// This function returns the conversion options of every field. The
+// field options take precedence over the default ones
+func (t *typeInfo) convOptions(defaults convOptions) []convOptions {
+	opts := make([]convOptions, 0, len(t.fields))
+	for _, field := range t.fields {
+		fieldOpts := defaults
+		if field.layout != "" {
+			fieldOpts.layout = field.layout
 		}
-		value.SetString(val)
-	case bool:
-		val, err := toBool(valStr)
-		if err != nil {
-			return err
+		if field.location != nil {
+			fieldOpts.location = field.location
 		}
-		value.SetBool(val)
-	case int8, int16, int32, int64:
-		val, err := toInt(valStr)
-		if err != nil {
-			return err
+		if fieldOpts.layout == "" {
+			fieldOpts.layout = DefaultTimeLayout
 		}
-		value.SetInt(val)
-	case uint8, uint16, uint32, uint64:
-		val, err := toUint(valStr)
-		if err != nil {
-			return err
+		opts = append(opts, fieldOpts)
+	}
+	return opts
+}
+
+// This type converts a CSV value and sets it into a field
+type decodeFunc func(value reflect.Value, valStr string, opts *convOptions) error
+
+// This type converts the value of a field into a CSV value
+type encodeFunc func(value reflect.Value, opts *convOptions) (string, error)
+
+// This function returns the decodeFunc of a type. The conversion is
+// chosen once per type, checking the time types, the Unmarshaler and
+// encoding.TextUnmarshaler interfaces and the underlying kind in order
+func newDecodeFunc(t reflect.Type) decodeFunc {
+	if t.Kind() == reflect.Pointer {
+		decodeElem := newDecodeFunc(t.Elem())
+		return func(value reflect.Value, valStr string, opts *convOptions) error {
+			// An empty value leaves the pointer nil
+			if valStr == "" {
+				value.SetZero()
+				return nil
+			}
+			if value.IsNil() {
+				value.Set(reflect.New(t.Elem()))
+			}
+			return decodeElem(value.Elem(), valStr, opts)
 		}
-		value.SetUint(val)
-	case float32, float64:
-		val, err := toFloat(valStr)
-		if err != nil {
-			return err
+	}
+
+	switch t {
+	case timeType:
+		return func(value reflect.Value, valStr string, opts *convOptions) error {
+			val, err := toTime(valStr, opts)
+			if err != nil {
+				return err
+			}
+			// Setting through a pointer avoids boxing the value
+			if value.CanAddr() {
+				*value.Addr().Interface().(*time.Time) = val
+			} else {
+				value.Set(reflect.ValueOf(val))
+			}
+			return nil
 		}
-		value.SetFloat(val)
-	default:
-		// Check if interface of Unmarshaler and call Unmarshal method
-		if interfaceVal, ok := reflect.New(value.Type()).Interface().(Unmarshaler); ok {
-			if err := interfaceVal.(Unmarshaler).UnmarshalCSV(valStr); err != nil {
+	case durationType:
+		return func(value reflect.Value, valStr string, opts *convOptions) error {
+			val, err := toDuration(valStr)
+			if err != nil {
 				return err
 			}
-			value.Set(reflect.ValueOf(interfaceVal).Elem())
-		} else {
-			// TODO: check if type is subtype of a primitive type
+			value.SetInt(int64(val))
+			return nil
 		}
 	}
 
-	return nil
-}
-
-func toString(val any) (outStr string, err error) {
-	inVal := reflect.ValueOf(val)
+	// Check if interface of Unmarshaler or encoding.TextUnmarshaler
+	// and call its Unmarshal method
+	ptrType := reflect.PointerTo(t)
+	switch {
+	case ptrType.Implements(unmarshalerType):
+		return addrDecodeFunc(t, func(ptr any, valStr string) error {
+			return ptr.(Unmarshaler).UnmarshalCSV(valStr)
+		})
+	case ptrType.Implements(textUnmarshalerType):
+		return addrDecodeFunc(t, func(ptr any, valStr string) error {
+			return ptr.(encoding.TextUnmarshaler).UnmarshalText([]byte(valStr))
+		})
+	}
 
-	switch inVal.Kind() {
+	// Named types are converted through their underlying kind
+	switch t.Kind() {
 	case reflect.String:
-		return inVal.String(), nil
+		return func(value reflect.Value, valStr string, opts *convOptions) error {
+			// The value may share its memory with the rest of the record
+			if opts.cloneStrings {
+				valStr = strings.Clone(valStr)
+			}
+			value.SetString(valStr)
+			return nil
+		}
 	case reflect.Bool:
-		b := "false"
-		if inVal.Bool() {
-			b = "true"
-		}
-		return b, nil
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return fmt.Sprintf("%v", inVal.Int()), nil
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return fmt.Sprintf("%v", inVal.Uint()), nil
-	case reflect.Float32:
-		return strconv.FormatFloat(inVal.Float(), byte('f'), -1, 32), nil
-	case reflect.Float64:
-		return strconv.FormatFloat(inVal.Float(), byte('f'), -1, 64), nil
-	default:
-		newVal := reflect.New(inVal.Type())
-		if interfaceVal, ok := newVal.Interface().(Marshaler); ok {
-			newVal.Elem().Set(inVal)
-			if outStr, err = interfaceVal.(Marshaler).MarshalCSV(); err != nil {
-				return "", err
-			}
-			return outStr, err
-		} else {
-			// TODO: check if type if subtype of primitive type
-		}
-	}
-
-	return "", fmt.Errorf("unknown conversion from %s to string", inVal.Kind())
+		return func(value reflect.Value, valStr string, _ *convOptions) error {
+			val, err := toBool(valStr)
+			if err != nil {
+				return err
+			}
+			value.SetBool(val)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(value reflect.Value, valStr string, _ *convOptions) error {
+			val, err := toInt(valStr)
+			if err != nil {
+				return err
+			}
+			if value.OverflowInt(val) {
+				return fmt.Errorf("value %d overflows %s", val, t)
+			}
+			value.SetInt(val)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(value reflect.Value, valStr string, _ *convOptions) error {
+			val, err := toUint(valStr)
+			if err != nil {
+				return err
+			}
+			if value.OverflowUint(val) {
+				return fmt.Errorf("value %d overflows %s", val, t)
+			}
+			value.SetUint(val)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(value reflect.Value, valStr string, _ *convOptions) error {
+			val, err := toFloat(valStr)
+			if err != nil {
+				return err
+			}
+			if value.OverflowFloat(val) {
+				return fmt.Errorf("value %v overflows %s", val, t)
+			}
+			value.SetFloat(val)
+			return nil
+		}
+	}
+
+	return func(reflect.Value, string, *convOptions) error {
+		return fmt.Errorf("unknown conversion from string to %s", t)
+	}
 }
 
-func toBool(valStr string) (bool, error) {
-	return strconv.ParseBool(valStr)
+// This function returns a decodeFunc that calls the unmarshal function
+// with a pointer to the value. Values that are not addressable are
+// unmarshaled into a new value which is set afterwards
+func addrDecodeFunc(t reflect.Type, unmarshal func(ptr any, valStr string) error) decodeFunc {
+	return func(value reflect.Value, valStr string, _ *convOptions) error {
+		if value.CanAddr() {
+			return unmarshal(value.Addr().Interface(), valStr)
+		}
+
+		ptr := reflect.New(t)
+		if err := unmarshal(ptr.Interface(), valStr); err != nil {
+			return err
+		}
+		value.Set(ptr.Elem())
+		return nil
+	}
 }
 
-func toInt(val any) (int64, error) {
-	inVal := reflect.ValueOf(val)
+// This function returns the encodeFunc of a type. The conversion is
+// chosen once per type, checking the time types, the Marshaler and
+// encoding.TextMarshaler interfaces and the underlying kind in order
+func newEncodeFunc(t reflect.Type) encodeFunc {
+	if t.Kind() == reflect.Pointer {
+		encodeElem := newEncodeFunc(t.Elem())
+		return func(value reflect.Value, opts *convOptions) (string, error) {
+			if value.IsNil() {
+				return "", nil
+			}
+			return encodeElem(value.Elem(), opts)
+		}
+	}
+
+	switch t {
+	case timeType:
+		return func(value reflect.Value, opts *convOptions) (string, error) {
+			var val time.Time
+			if value.CanAddr() {
+				val = *value.Addr().Interface().(*time.Time)
+			} else {
+				val = value.Interface().(time.Time)
+			}
+			// The zero time is encoded as an empty value
+			if val.IsZero() {
+				return "", nil
+			}
+			if opts.location != nil {
+				val = val.In(opts.location)
+			}
+			return val.Format(opts.layout), nil
+		}
+	case durationType:
+		return func(value reflect.Value, _ *convOptions) (string, error) {
+			return time.Duration(value.Int()).String(), nil
+		}
+	}
+
+	// Check if interface of Marshaler or encoding.TextMarshaler
+	// and call its Marshal method
+	ptrType := reflect.PointerTo(t)
+	switch {
+	case ptrType.Implements(marshalerType):
+		return addrEncodeFunc(t, func(ptr any) (string, error) {
+			return ptr.(Marshaler).MarshalCSV()
+		})
+	case ptrType.Implements(textMarshalerType):
+		return addrEncodeFunc(t, func(ptr any) (string, error) {
+			text, err := ptr.(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		})
+	}
 
-	switch inVal.Kind() {
+	// Named types are converted through their underlying kind
+	switch t.Kind() {
 	case reflect.String:
-		str := strings.TrimSpace(inVal.String())
-		if str == "" {
-			return 0, nil
+		return func(value reflect.Value, _ *convOptions) (string, error) {
+			return value.String(), nil
 		}
-		splitted := strings.SplitN(str, ".", 2)
-		return strconv.ParseInt(splitted[0], 0, 64)
 	case reflect.Bool:
-		if inVal.Bool() {
-			return 1, nil
+		return func(value reflect.Value, _ *convOptions) (string, error) {
+			return strconv.FormatBool(value.Bool()), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(value reflect.Value, _ *convOptions) (string, error) {
+			return strconv.FormatInt(value.Int(), 10), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(value reflect.Value, _ *convOptions) (string, error) {
+			return strconv.FormatUint(value.Uint(), 10), nil
 		}
-		return 0, nil
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return inVal.Int(), nil
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return int64(inVal.Uint()), nil
 	case reflect.Float32, reflect.Float64:
-		return int64(inVal.Float()), nil
+		bitSize := t.Bits()
+		return func(value reflect.Value, _ *convOptions) (string, error) {
+			return strconv.FormatFloat(value.Float(), byte('f'), -1, bitSize), nil
+		}
 	}
 
-	return 0, fmt.Errorf("unknown conversion from %v to int", inVal.Kind())
+	return func(reflect.Value, *convOptions) (string, error) {
+		return "", fmt.Errorf("unknown conversion from %s to string", t)
+	}
 }
 
-func toUint(val any) (uint64, error) {
-	inVal := reflect.ValueOf(val)
-
-	switch inVal.Kind() {
-	case reflect.String:
-		str := strings.TrimSpace(inVal.String())
-		if str == "" {
-			return 0, nil
-		}
-		splitted := strings.SplitN(str, ".", 2)
-		return strconv.ParseUint(splitted[0], 0, 64)
-	case reflect.Bool:
-		if inVal.Bool() {
-			return 1, nil
+// This function returns an encodeFunc that calls the marshal function
+// with a pointer to the value. Values that are not addressable are
+// copied into a new value first
+func addrEncodeFunc(t reflect.Type, marshal func(ptr any) (string, error)) encodeFunc {
+	return func(value reflect.Value, _ *convOptions) (string, error) {
+		if value.CanAddr() {
+			return marshal(value.Addr().Interface())
 		}
+
+		ptr := reflect.New(t)
+		ptr.Elem().Set(value)
+		return marshal(ptr.Interface())
+	}
+}
+
+func toTime(valStr string, opts *convOptions) (time.Time, error) {
+	str := strings.TrimSpace(valStr)
+	if str == "" {
+		return time.Time{}, nil
+	}
+	location := opts.location
+	if location == nil {
+		location = time.UTC
+	}
+	return time.ParseInLocation(opts.layout, str, location)
+}
+
+func toDuration(valStr string) (time.Duration, error) {
+	str := strings.TrimSpace(valStr)
+	if str == "" {
 		return 0, nil
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return uint64(inVal.Uint()), nil
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return inVal.Uint(), nil
-	case reflect.Float32, reflect.Float64:
-		return uint64(inVal.Float()), nil
 	}
+	return time.ParseDuration(str)
+}
 
-	return 0, fmt.Errorf("unknown conversion from %v to uint", inVal.Kind())
+func toBool(valStr string) (bool, error) {
+	return strconv.ParseBool(valStr)
 }
 
-func toFloat(val any) (float64, error) {
-	inVal := reflect.ValueOf(val)
+func toInt(valStr string) (int64, error) {
+	str := strings.TrimSpace(valStr)
+	if str == "" {
+		return 0, nil
+	}
+	splitted, _, _ := strings.Cut(str, ".")
+	return strconv.ParseInt(splitted, 0, 64)
+}
 
-	switch inVal.Kind() {
-	case reflect.String:
-		str := strings.TrimSpace(inVal.String())
-		if str == "" {
-			return 0, nil
-		}
-		return strconv.ParseFloat(str, 64)
-	case reflect.Bool:
-		if inVal.Bool() {
-			return 1, nil
-		}
+func toUint(valStr string) (uint64, error) {
+	str := strings.TrimSpace(valStr)
+	if str == "" {
 		return 0, nil
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return float64(inVal.Uint()), nil
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return float64(inVal.Uint()), nil
-	case reflect.Float32, reflect.Float64:
-		return inVal.Float(), nil
 	}
+	splitted, _, _ := strings.Cut(str, ".")
+	return strconv.ParseUint(splitted, 0, 64)
+}
 
-	return 0, fmt.Errorf("unknown conversion from %v to float", inVal.Kind())
+func toFloat(valStr string) (float64, error) {
+	str := strings.TrimSpace(valStr)
+	if str == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(str, 64)
 }